@@ -4,6 +4,7 @@ package main
 
 import (
 	"context"
+	"os"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
@@ -56,7 +57,11 @@ func Main(ctx context.Context) error {
 		}
 	}()
 
-	gocore.Setuid() // set to grafana user for open of the grpc unix socket in Manage()
+	// GOMON_SKIP_SETUID keeps the current user when the operator intentionally runs the plugin
+	// unprivileged; data source settings are not available until after Manage() starts
+	if os.Getenv("GOMON_SKIP_SETUID") == "" {
+		gocore.Setuid() // set to grafana user for open of the grpc unix socket in Manage()
+	}
 	return gocore.Error(
 		"datasource exit",
 		datasource.Manage("zosmac-gomon-datasource",
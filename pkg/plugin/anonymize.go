@@ -0,0 +1,171 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/zosmac/gomon/process"
+)
+
+var (
+	// anonymizeKey keys the hash of anonymized names, so tokens are stable for the life of the plugin
+	// but cannot be matched across sessions.
+	anonymizeKey = func() []byte {
+		key := make([]byte, 32)
+		rand.Read(key)
+		return key
+	}()
+
+	// anonymizeDetails identifies the node details that anonymize replaces.
+	anonymizeDetails = []string{"user", "cmdline", "reexec"}
+)
+
+// anonymize returns a copy of the graph with its host, process, user, and file names replaced by opaque tokens.
+// The same name always yields the same token, so the shape of the graph is preserved.
+// Loopback addresses and ports remain readable.
+func anonymize(g graph) graph {
+	a := g
+	a.nodes = make([][]any, len(g.nodes))
+	for i, n := range g.nodes {
+		n = slices.Clone(n)
+		switch id := n[0].(int64); {
+		case id < 0: // host: type:port, hostname, address
+			n[2], n[3] = opaqueHost(n[2].(string)), opaqueHost(n[3].(string))
+		case isClosed(Pid(id)): // closed peer: closed, address, type:address
+			n[2], n[3] = opaqueAddress(n[2].(string)), opaqueEndpoint(n[3].(string))
+		case isData(Pid(id)): // data: type, name, type:name
+			n[2], n[3] = opaque(n[2].(string)), opaqueEndpoint(n[3].(string))
+		default: // process: name, pid, longname
+			n[1], n[3] = opaque(n[1].(string)), opaqueAddress(n[3].(string))
+			for j, detail := range nodeDetails {
+				if slices.Contains(anonymizeDetails, detail.path) {
+					n[4+arcs+j] = opaque(n[4+arcs+j].(string))
+				}
+			}
+			n[warningsDetail] = opaqueWarnings(n[warningsDetail].(string))
+		}
+		n[hostDetail] = opaqueHost(n[hostDetail].(string))
+		a.nodes[i] = n
+	}
+
+	a.edges = make([][]any, len(g.edges))
+	for i, e := range g.edges {
+		e = slices.Clone(e)
+		e[3], e[4] = opaqueEndpoint(e[3].(string)), opaqueEndpoint(e[4].(string))
+		for j := connIndex; j < len(e); j++ {
+			self, peer, _ := strings.Cut(e[j].(string), " -> ")
+			e[j] = opaqueEndpoint(self) + " -> " + opaqueEndpoint(peer)
+		}
+		a.edges[i] = e
+	}
+
+	return a
+}
+
+// anonymizeTableEntry returns a copy of a table resource entry with its names and connection endpoints
+// replaced by opaque tokens, consistent with those of the node graph.
+func anonymizeTableEntry(entry tableEntry) tableEntry {
+	entry.Name = opaque(entry.Name)
+	entry.Executable = opaque(entry.Executable)
+	entry.User = opaque(entry.User)
+	conns := make([]process.Connection, len(entry.Connections))
+	for i, conn := range entry.Connections {
+		conn.Self.Name = opaqueAddress(conn.Self.Name)
+		conn.Peer.Name = opaqueAddress(conn.Peer.Name)
+		conns[i] = conn
+	}
+	entry.Connections = conns
+	return entry
+}
+
+// anonymizePidEntry returns a copy of a pids resource entry with its names replaced by opaque tokens.
+func anonymizePidEntry(entry pidEntry) pidEntry {
+	entry.Name = opaque(entry.Name)
+	entry.Executable = opaque(entry.Executable)
+	entry.User = opaque(entry.User)
+	return entry
+}
+
+// anonymizeChanges returns a copy of a changes resource report with its names and endpoints
+// replaced by opaque tokens.
+func anonymizeChanges(c changes) changes {
+	processes := func(pcs []processChange) []processChange {
+		a := make([]processChange, len(pcs))
+		for i, pc := range pcs {
+			pc.Executable = opaque(pc.Executable)
+			a[i] = pc
+		}
+		return a
+	}
+	connections := func(ccs []connectionChange) []connectionChange {
+		a := make([]connectionChange, len(ccs))
+		for i, cc := range ccs {
+			cc.Executable = opaque(cc.Executable)
+			cc.Self, cc.Peer = opaqueAddress(cc.Self), opaqueAddress(cc.Peer)
+			a[i] = cc
+		}
+		return a
+	}
+	c.Appeared, c.Exited = processes(c.Appeared), processes(c.Exited)
+	c.Opened, c.Closed = connections(c.Opened), connections(c.Closed)
+	return c
+}
+
+// opaqueWarnings anonymizes the executable named by a process' re-exec warning,
+// leaving the reasons of the warnings readable.
+func opaqueWarnings(s string) string {
+	if s == "" {
+		return ""
+	}
+	reasons := strings.Split(s, "; ")
+	for i, reason := range reasons {
+		if exe, ok := strings.CutPrefix(reason, reexecWarning); ok {
+			reasons[i] = reexecWarning + opaque(exe)
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// opaqueEndpoint anonymizes an endpoint described as an optional type prefix and a host:port, a name[pid], or a name.
+func opaqueEndpoint(s string) string {
+	if typ, rest, ok := strings.Cut(s, ":"); ok && !strings.ContainsAny(typ, "[/") {
+		return typ + ":" + opaqueAddress(rest)
+	}
+	return opaqueAddress(s)
+}
+
+// opaqueAddress anonymizes the host of a host:port, the name of a name[pid], or else the whole name.
+func opaqueAddress(s string) string {
+	if host, port, err := net.SplitHostPort(s); err == nil {
+		return net.JoinHostPort(opaqueHost(host), port)
+	}
+	if name, pid, ok := strings.Cut(s, "["); ok && strings.HasSuffix(pid, "]") {
+		return opaque(name) + "[" + pid
+	}
+	return opaqueHost(s)
+}
+
+// opaqueHost anonymizes a host name or address, leaving loopback hosts readable.
+func opaqueHost(host string) string {
+	if ip := net.ParseIP(host); host == "localhost" || ip != nil && ip.IsLoopback() {
+		return host
+	}
+	return opaque(host)
+}
+
+// opaque hashes a name to a stable, opaque token.
+func opaque(name string) string {
+	if name == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, anonymizeKey)
+	mac.Write([]byte(name))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
@@ -0,0 +1,94 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zosmac/gomon/process"
+)
+
+func TestAnonymizeProcess(t *testing.T) {
+	query := testQuery(queryModel{}, dataSourceSettings{})
+	p := testProcess(20, 1, "server")
+	p.Id.Starttime = time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+	p.Args = []string{"--token=secret"}
+	tb := process.Table{p.Pid: p}
+
+	g := graph{nodes: [][]any{query.ProcNode(p)}}
+	query.stableIds(tb, &g)
+	if id := Pid(g.nodes[0][0].(int64)); id <= 1<<32 {
+		t.Fatalf("process id %#x is not qualified by its start time", id)
+	}
+
+	n := anonymize(g).nodes[0]
+	if n[1] == "server" || strings.Contains(n[3].(string), "server") {
+		t.Errorf("process name is readable: %q, %q", n[1], n[3])
+	}
+	if n[userDetail] == "" || n[userDetail] == "user" {
+		t.Errorf("user is readable: %q", n[userDetail])
+	}
+	if cl := n[detailIndex("cmdline")].(string); cl == "" || strings.Contains(cl, "secret") {
+		t.Errorf("command line is readable: %q", cl)
+	}
+	if n[0] != g.nodes[0][0] {
+		t.Errorf("anonymize changed the id %#x to %#x", g.nodes[0][0], n[0])
+	}
+}
+
+func TestAnonymizeResources(t *testing.T) {
+	conn := testConnection("TCP", 20, "10.1.2.3:443", 0, "example.com:51234")
+	entry := anonymizeTableEntry(tableEntry{
+		Pid:         20,
+		Name:        "server",
+		Executable:  "/usr/bin/server",
+		User:        "user",
+		Connections: []process.Connection{conn},
+	})
+	if entry.Name == "server" || entry.Executable == "/usr/bin/server" || entry.User == "user" {
+		t.Errorf("table entry names are readable: %+v", entry)
+	}
+	if self := entry.Connections[0].Self.Name; strings.HasPrefix(self, "10.1.2.3") || !strings.HasSuffix(self, ":443") {
+		t.Errorf("connection endpoint %q is not anonymized to host:port", self)
+	}
+	if conn.Self.Name != "10.1.2.3:443" {
+		t.Error("anonymize modified the process table's connection")
+	}
+
+	if e := anonymizePidEntry(pidEntry{Pid: 20, Name: "server", User: "user"}); e.Name == "server" || e.User == "user" {
+		t.Errorf("pid entry names are readable: %+v", e)
+	}
+
+	c := anonymizeChanges(changes{
+		Appeared: []processChange{{Pid: 20, Executable: "/usr/bin/server"}},
+		Opened: []connectionChange{{
+			Pid:        20,
+			Executable: "/usr/bin/server",
+			Type:       "TCP",
+			Self:       "127.0.0.1:443",
+			Peer:       "example.com:51234",
+		}},
+	})
+	if c.Appeared[0].Executable == "/usr/bin/server" || c.Opened[0].Executable == "/usr/bin/server" {
+		t.Errorf("changed executables are readable: %+v", c)
+	}
+	if c.Opened[0].Self != "127.0.0.1:443" || strings.HasPrefix(c.Opened[0].Peer, "example.com") {
+		t.Errorf("changed connection endpoints %q, %q are not anonymized", c.Opened[0].Self, c.Opened[0].Peer)
+	}
+}
+
+func TestOpaqueWarnings(t *testing.T) {
+	s := opaqueWarnings("running as root; " + reexecWarning + "/tmp/dropper; connected to 12 remote hosts")
+	reasons := strings.Split(s, "; ")
+	if len(reasons) != 3 || reasons[0] != "running as root" || reasons[2] != "connected to 12 remote hosts" {
+		t.Errorf("warning reasons are not readable: %q", s)
+	}
+	if !strings.HasPrefix(reasons[1], reexecWarning) || strings.Contains(reasons[1], "dropper") {
+		t.Errorf("re-exec warning %q does not anonymize only the executable", reasons[1])
+	}
+	if opaqueWarnings("") != "" {
+		t.Error("no warnings anonymized to a token")
+	}
+}
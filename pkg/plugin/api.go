@@ -0,0 +1,132 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+type (
+	// Options are the options of a graph, as for a query of the datasource.
+	Options = queryModel
+
+	// Settings are the settings of a graph, as for the datasource's configuration.
+	Settings = dataSourceSettings
+
+	// Node is a host, process, or data node of a graph.
+	Node struct {
+		ID            int64              `json:"id"`
+		MainStat      string             `json:"mainStat"`
+		SecondaryStat string             `json:"secondaryStat"`
+		Name          string             `json:"name"`
+		Arcs          map[string]float64 `json:"arcs"`    // the node's arc by arc field path
+		Details       map[string]any     `json:"details"` // the node's details by detail field path
+		Color         string             `json:"color,omitempty"`
+	}
+
+	// Edge is a connection between two nodes of a graph.
+	Edge struct {
+		ID            string         `json:"id"`
+		Source        int64          `json:"source"`
+		Target        int64          `json:"target"`
+		MainStat      string         `json:"mainStat"`
+		SecondaryStat string         `json:"secondaryStat"`
+		Details       map[string]any `json:"details"` // the edge's details by detail field path, omitting null values
+		Connections   []string       `json:"connections"`
+	}
+
+	// Graph is a node graph of the processes and their connections as plain Go values, for programs that do not
+	// depend on the Grafana SDK.
+	Graph struct {
+		Timestamp time.Time `json:"timestamp"`
+		Nodes     []Node    `json:"nodes"`
+		Edges     []Edge    `json:"edges"`
+		Notices   []string  `json:"notices"`
+	}
+)
+
+// BuildGraph builds the node graph that a query with the options reports to a datasource with the settings.
+func BuildGraph(ctx context.Context, opts Options, settings Settings) (Graph, error) {
+	if err := opts.validate(); err != nil {
+		return Graph{}, fmt.Errorf("invalid options: %w", err)
+	}
+	if err := settings.parse(); err != nil {
+		return Graph{}, fmt.Errorf("invalid settings: %w", err)
+	}
+	g := buildGraph(ctx, opts, settings)
+	if settings.Anonymize {
+		g = anonymize(g)
+	}
+	return plainGraph(g), nil
+}
+
+// plainGraph converts the rows of a graph's nodes and edges, laid out as their frames' fields, to plain Go values.
+func plainGraph(g graph) Graph {
+	pg := Graph{
+		Timestamp: g.timestamp,
+		Nodes:     make([]Node, 0, len(g.nodes)),
+		Edges:     make([]Edge, 0, len(g.edges)),
+	}
+	for _, n := range g.nodes {
+		node := Node{
+			ID:            n[0].(int64),
+			MainStat:      n[1].(string),
+			SecondaryStat: n[2].(string),
+			Name:          n[3].(string),
+			Arcs:          map[string]float64{},
+			Details:       map[string]any{},
+		}
+		for i, a := range arcFields {
+			if v := n[4+i].(float64); v != 0 {
+				node.Arcs[a.path] = v
+			}
+		}
+		for i, detail := range nodeDetails {
+			if v, ok := plainValue(n[4+arcs+i]); ok {
+				node.Details[detail.path] = v
+			}
+		}
+		if g.colorByName {
+			node.Color = n[4+arcs+len(nodeDetails)].(string)
+		}
+		pg.Nodes = append(pg.Nodes, node)
+	}
+	for _, e := range g.edges {
+		edge := Edge{
+			ID:            e[0].(string),
+			Source:        e[1].(int64),
+			Target:        e[2].(int64),
+			MainStat:      e[3].(string),
+			SecondaryStat: e[4].(string),
+			Details:       map[string]any{},
+		}
+		for i, detail := range edgeDetails {
+			if v, ok := plainValue(e[5+i]); ok {
+				edge.Details[detail.path] = v
+			}
+		}
+		for _, conn := range e[connIndex:] {
+			edge.Connections = append(edge.Connections, conn.(string))
+		}
+		pg.Edges = append(pg.Edges, edge)
+	}
+	for _, notice := range g.notices {
+		pg.Notices = append(pg.Notices, notice.Text)
+	}
+	return pg
+}
+
+// plainValue dereferences the value of a nullable field, reporting false for a null value.
+func plainValue(v any) (any, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer {
+		return v, true
+	}
+	if rv.IsNil() {
+		return nil, false
+	}
+	return rv.Elem().Interface(), true
+}
@@ -0,0 +1,60 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/zosmac/gomon/process"
+)
+
+type (
+	// boundary identifies the trust boundary of a process: its user, container, and pid namespace.
+	boundary struct {
+		user      string
+		container string
+		namespace string
+	}
+)
+
+// crossBoundary keeps only the edges between processes of different users, containers, or pid namespaces,
+// dropping the host and data edges, and records the processes left without edges as dropped.
+// Without the processes' users, the filter is skipped with a notice.
+func (query Query) crossBoundary(tb process.Table, itr process.Tree, edges map[[2]Pid][]any, dropped map[Pid]struct{}) {
+	boundaries := map[Pid]boundary{}
+	users := false
+	for _, pid := range itr.All() {
+		p := tb[pid]
+		if p == nil {
+			continue
+		}
+		b := boundary{user: username(p)}
+		if query.live { // a replayed table records no containers or namespaces
+			b.container = container(pid)
+			b.namespace, _ = pidNamespace(pid)
+		}
+		boundaries[pid] = b
+		users = users || p.Username != ""
+	}
+	if !users {
+		query.notices.add(data.NoticeSeverityWarning, "crossBoundaryOnly ignored, the processes' users are unknown")
+		return
+	}
+
+	for id := range edges {
+		self, ok := boundaries[id[0]]
+		peer, ok2 := boundaries[id[1]]
+		if !ok || !ok2 || self == peer {
+			delete(edges, id)
+		}
+	}
+
+	connected := map[Pid]struct{}{}
+	if query.model.Pid > 0 {
+		connected[query.model.Pid] = struct{}{}
+	}
+	for id := range edges {
+		connected[id[0]] = struct{}{}
+		connected[id[1]] = struct{}{}
+	}
+	query.retain(tb, itr, edges, connected, dropped)
+}
@@ -0,0 +1,97 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"bufio"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	// capabilityNames decodes the bits of a capability set, per capabilities(7).
+	capabilityNames = [...]string{
+		"CAP_CHOWN", "CAP_DAC_OVERRIDE", "CAP_DAC_READ_SEARCH", "CAP_FOWNER",
+		"CAP_FSETID", "CAP_KILL", "CAP_SETGID", "CAP_SETUID",
+		"CAP_SETPCAP", "CAP_LINUX_IMMUTABLE", "CAP_NET_BIND_SERVICE", "CAP_NET_BROADCAST",
+		"CAP_NET_ADMIN", "CAP_NET_RAW", "CAP_IPC_LOCK", "CAP_IPC_OWNER",
+		"CAP_SYS_MODULE", "CAP_SYS_RAWIO", "CAP_SYS_CHROOT", "CAP_SYS_PTRACE",
+		"CAP_SYS_PACCT", "CAP_SYS_ADMIN", "CAP_SYS_BOOT", "CAP_SYS_NICE",
+		"CAP_SYS_RESOURCE", "CAP_SYS_TIME", "CAP_SYS_TTY_CONFIG", "CAP_MKNOD",
+		"CAP_LEASE", "CAP_AUDIT_WRITE", "CAP_AUDIT_CONTROL", "CAP_SETFCAP",
+		"CAP_MAC_OVERRIDE", "CAP_MAC_ADMIN", "CAP_SYSLOG", "CAP_WAKE_ALARM",
+		"CAP_BLOCK_SUSPEND", "CAP_AUDIT_READ", "CAP_PERFMON", "CAP_BPF",
+		"CAP_CHECKPOINT_RESTORE",
+	}
+
+	// notableCapabilities are the capabilities that grant broad privilege.
+	notableCapabilities = map[string]bool{
+		"CAP_DAC_OVERRIDE":    true,
+		"CAP_DAC_READ_SEARCH": true,
+		"CAP_SETUID":          true,
+		"CAP_SETGID":          true,
+		"CAP_NET_ADMIN":       true,
+		"CAP_NET_RAW":         true,
+		"CAP_SYS_MODULE":      true,
+		"CAP_SYS_RAWIO":       true,
+		"CAP_SYS_PTRACE":      true,
+		"CAP_SYS_ADMIN":       true,
+		"CAP_SYS_BOOT":        true,
+		"CAP_MAC_OVERRIDE":    true,
+		"CAP_MAC_ADMIN":       true,
+		"CAP_PERFMON":         true,
+		"CAP_BPF":             true,
+	}
+
+	// seccompModes names the seccomp modes of a process' status.
+	seccompModes = map[string]string{
+		"0": "disabled",
+		"1": "strict",
+		"2": "filter",
+	}
+)
+
+// capabilities reads the notable capabilities of the process' effective set and its seccomp mode from its status.
+// Capabilities newer than capabilityNames are reported as cap_N.
+func capabilities(pid Pid) (caps, seccomp string) {
+	f, err := os.Open(filepath.Join("/proc", pid.String(), "status"))
+	if err != nil {
+		return "", ""
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		key, value, _ := strings.Cut(sc.Text(), ":")
+		value = strings.TrimSpace(value)
+		switch key {
+		case "CapEff":
+			caps = capabilityList(value)
+		case "Seccomp":
+			seccomp = seccompModes[value]
+		}
+	}
+	return caps, seccomp
+}
+
+// capabilityList decodes the notable capabilities of a hexadecimal capability set.
+func capabilityList(set string) string {
+	mask, err := strconv.ParseUint(set, 16, 64)
+	if err != nil {
+		return ""
+	}
+	var names []string
+	for mask != 0 {
+		bit := bits.TrailingZeros64(mask)
+		mask &^= 1 << bit
+		if bit >= len(capabilityNames) {
+			names = append(names, "cap_"+strconv.Itoa(bit))
+		} else if notableCapabilities[capabilityNames[bit]] {
+			names = append(names, capabilityNames[bit])
+		}
+	}
+	return strings.Join(names, ",")
+}
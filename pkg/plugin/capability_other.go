@@ -0,0 +1,10 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+//go:build !linux
+
+package plugin
+
+// capabilities are only determined for Linux processes.
+func capabilities(Pid) (caps, seccomp string) {
+	return "", ""
+}
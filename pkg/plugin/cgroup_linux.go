@@ -0,0 +1,65 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// containerRegex matches the container id in a docker, containerd, cri-o, or podman cgroup path.
+	containerRegex = regexp.MustCompile(
+		`(?:docker|libpod|containerd|crio|kubepods)[^/]*[-/](?:[^/]*/)*?([0-9a-f]{64})(?:\.scope)?(?:/|$)`,
+	)
+
+	// unitSuffixes are the suffixes of the systemd unit types that own processes.
+	unitSuffixes = []string{".service", ".scope", ".slice"}
+)
+
+// container reads the process' cgroup to determine the id of its container.
+// Processes in the host namespace report an empty container.
+func container(pid Pid) string {
+	f, err := os.Open(filepath.Join("/proc", pid.String(), "cgroup"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if match := containerRegex.FindStringSubmatch(sc.Text()); match != nil {
+			return match[1][:12] // the short form of the container id
+		}
+	}
+
+	return ""
+}
+
+// unit reads the process' cgroup to determine the systemd unit that owns it, the innermost unit of its cgroup path.
+// Processes outside of systemd units report an empty unit.
+func unit(pid Pid) string {
+	f, err := os.Open(filepath.Join("/proc", pid.String(), "cgroup"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		_, path, _ := strings.Cut(sc.Text(), "::") // the unified hierarchy
+		names := strings.Split(path, "/")
+		for i := len(names) - 1; i >= 0; i-- {
+			for _, suffix := range unitSuffixes {
+				if strings.HasSuffix(names[i], suffix) {
+					return names[i]
+				}
+			}
+		}
+	}
+
+	return ""
+}
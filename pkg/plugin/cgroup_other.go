@@ -0,0 +1,15 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+//go:build !linux
+
+package plugin
+
+// container is only determined for Linux processes.
+func container(Pid) string {
+	return ""
+}
+
+// unit is only determined for Linux processes.
+func unit(Pid) string {
+	return ""
+}
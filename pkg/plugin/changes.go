@@ -0,0 +1,142 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/zosmac/gomon/process"
+)
+
+type (
+	// tableState records the processes and connections of a process table at a point in time.
+	tableState struct {
+		timestamp   time.Time
+		processes   map[Pid]processChange
+		connections map[connectionKey]connectionChange
+	}
+
+	// connectionKey identifies a connection across process tables, whose host and data pids are not stable.
+	connectionKey struct {
+		Type, Self, Peer string
+		Pid              Pid
+	}
+
+	// processChange reports a process that appeared or exited.
+	processChange struct {
+		Pid        Pid    `json:"pid"`
+		Executable string `json:"executable"`
+	}
+
+	// connectionChange reports a connection that opened or closed.
+	connectionChange struct {
+		Pid        Pid    `json:"pid"`
+		Executable string `json:"executable"`
+		Type       string `json:"type"`
+		Self       string `json:"self"`
+		Peer       string `json:"peer"`
+		PeerPid    Pid    `json:"peerPid"`
+	}
+
+	// changes reports the changes to the process table since the prior request.
+	changes struct {
+		From     time.Time          `json:"from"`
+		To       time.Time          `json:"to"`
+		Appeared []processChange    `json:"appeared"`
+		Exited   []processChange    `json:"exited"`
+		Opened   []connectionChange `json:"opened"`
+		Closed   []connectionChange `json:"closed"`
+		Baseline bool               `json:"baseline"` // no prior table to compare
+	}
+)
+
+var (
+	// lastTable is the process table of the prior changes request.
+	lastTable = struct {
+		sync.Mutex
+		state *tableState
+	}{}
+)
+
+// newTableState records the processes and connections of a process table.
+// The executables of processes that the settings deny are not recorded.
+func newTableState(tb process.Table, settings dataSourceSettings) *tableState {
+	state := &tableState{
+		timestamp:   time.Now(),
+		processes:   map[Pid]processChange{},
+		connections: map[connectionKey]connectionChange{},
+	}
+	for pid, p := range tb {
+		exe := p.Executable
+		if settings.denied(p) {
+			exe = ""
+		}
+		state.processes[pid] = processChange{Pid: pid, Executable: exe}
+		for _, conn := range p.Connections {
+			peer := conn.Peer.Pid
+			if !isProcess(peer) {
+				peer = 0 // host and data pids are assigned anew for each table
+			}
+			state.connections[connectionKey{conn.Type, conn.Self.Name, conn.Peer.Name, pid}] = connectionChange{
+				Pid:        pid,
+				Executable: exe,
+				Type:       conn.Type,
+				Self:       conn.Self.Name,
+				Peer:       conn.Peer.Name,
+				PeerPid:    peer,
+			}
+		}
+	}
+	return state
+}
+
+// diffTables compares the processes and connections of two process tables.
+func diffTables(prev, curr *tableState) changes {
+	c := changes{
+		From:     prev.timestamp,
+		To:       curr.timestamp,
+		Appeared: []processChange{},
+		Exited:   []processChange{},
+		Opened:   []connectionChange{},
+		Closed:   []connectionChange{},
+	}
+	for pid, p := range curr.processes {
+		if _, ok := prev.processes[pid]; !ok {
+			c.Appeared = append(c.Appeared, p)
+		}
+	}
+	for pid, p := range prev.processes {
+		if _, ok := curr.processes[pid]; !ok {
+			c.Exited = append(c.Exited, p)
+		}
+	}
+	for key, conn := range curr.connections {
+		if _, ok := prev.connections[key]; !ok {
+			c.Opened = append(c.Opened, conn)
+		}
+	}
+	for key, conn := range prev.connections {
+		if _, ok := curr.connections[key]; !ok {
+			c.Closed = append(c.Closed, conn)
+		}
+	}
+
+	byPid := func(a, b processChange) int { return cmp.Compare(a.Pid, b.Pid) }
+	slices.SortFunc(c.Appeared, byPid)
+	slices.SortFunc(c.Exited, byPid)
+	byConnection := func(a, b connectionChange) int {
+		return cmp.Or(
+			cmp.Compare(a.Pid, b.Pid),
+			cmp.Compare(a.Type, b.Type),
+			cmp.Compare(a.Self, b.Self),
+			cmp.Compare(a.Peer, b.Peer),
+		)
+	}
+	slices.SortFunc(c.Opened, byConnection)
+	slices.SortFunc(c.Closed, byConnection)
+
+	return c
+}
@@ -0,0 +1,105 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/zosmac/gomon/process"
+)
+
+// adoptOrphans reparents the processes whose parents exited while the table was collected, as the kernel would,
+// to init if it is in the table and otherwise to the root of the tree, so that the table's tree can be built.
+// The table and its processes are shared by snapshots, so an orphan is copied into a copy of the table.
+func adoptOrphans(tb process.Table) process.Table {
+	var adopted process.Table
+	for pid, p := range tb {
+		if p.Ppid <= 0 || tb[p.Ppid] != nil {
+			continue
+		}
+		if adopted == nil {
+			adopted = maps.Clone(tb)
+		}
+		orphan := *p
+		orphan.Ppid = 0
+		if tb[1] != nil && pid != 1 {
+			orphan.Ppid = 1
+		}
+		adopted[pid] = &orphan
+	}
+	if adopted == nil {
+		return tb
+	}
+	return adopted
+}
+
+// closedPeers replaces the edges between live and exited processes with edges to a closed node for each exited
+// peer, so that the half-open connections of the live processes remain visible. The other edges of exited
+// processes are removed. It returns the count of exited processes.
+func (query Query) closedPeers(tb process.Table, datas map[Pid][]any, edges map[[2]Pid][]any) int {
+	exited := map[Pid]struct{}{}
+	for id := range edges {
+		var live, gone Pid
+		switch {
+		case isProcess(id[0]) && tb[id[0]] == nil:
+			live, gone = id[1], id[0]
+		case isProcess(id[1]) && tb[id[1]] == nil:
+			live, gone = id[0], id[1]
+		default:
+			continue
+		}
+		exited[gone] = struct{}{}
+		delete(edges, id)
+		if !isProcess(live) {
+			continue
+		}
+		if tb[live] == nil { // both processes exited
+			exited[live] = struct{}{}
+			continue
+		}
+
+		cid := closedBase | gone
+		for _, conn := range tb[live].Connections {
+			if conn.Peer.Pid != gone {
+				continue
+			}
+			if _, ok := datas[cid]; !ok {
+				datas[cid] = query.closedNode(conn)
+			}
+			nid := [2]Pid{live, cid}
+			if _, ok := edges[nid]; !ok {
+				edges[nid] = query.closedEdge(tb, conn)
+			}
+			edges[nid] = append(edges[nid], fmt.Sprintf(
+				"%s"+query.Arrow()+"%s",
+				tb[live].Shortname(),
+				conn.Type+":"+conn.Peer.Name,
+			))
+		}
+	}
+	return len(exited)
+}
+
+// closedNode creates the node of an exited peer, labeled by the peer's address.
+func (query Query) closedNode(conn process.Connection) []any {
+	node := append(append([]any{
+		int64(closedBase | conn.Peer.Pid),
+		"closed",
+		conn.Peer.Name,
+		conn.Type + ":" + conn.Peer.Name,
+	}, arc(closedArc)...), pseudoDetails()...)
+	node[hostDetail] = localHost()
+	node[sizeDetail] = defaultSize
+	return node
+}
+
+// closedEdge creates the edge of a live process' half-open connection to an exited peer.
+func (query Query) closedEdge(tb process.Table, conn process.Connection) []any {
+	edge := query.DataEdge(tb, conn)
+	cid := closedBase | conn.Peer.Pid
+	edge[0] = fmt.Sprintf("%d -> %d", conn.Self.Pid, cid)
+	edge[2] = int64(cid)
+	edge[4] = "closed:" + conn.Peer.Name
+	return edge
+}
@@ -0,0 +1,133 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/zosmac/gomon/process"
+)
+
+func TestClosedPeers(t *testing.T) {
+	query := testQuery(queryModel{}, dataSourceSettings{})
+	tb := process.Table{
+		10: testProcess(10, 1, "client",
+			testConnection("TCP", 10, "127.0.0.1:41000", 20, "127.0.0.1:8080"),
+		),
+	}
+	datas := map[Pid][]any{}
+	edges := map[[2]Pid][]any{
+		{10, 20}: append(query.ProcEdge(tb, 10, 20), "TCP:127.0.0.1:41000[10] -> 127.0.0.1:8080[20]"),
+		{20, 30}: append(query.ProcEdge(tb, 20, 30), "parent:server[20] -> worker[30]"),
+	}
+
+	if exited := query.closedPeers(tb, datas, edges); exited != 2 {
+		t.Errorf("exited = %d, want 2", exited)
+	}
+
+	cid := Pid(closedBase | 20)
+	if _, ok := edges[[2]Pid{10, 20}]; ok {
+		t.Error("edge to the exited peer was not replaced")
+	}
+	if _, ok := edges[[2]Pid{20, 30}]; ok {
+		t.Error("edge between exited processes was not removed")
+	}
+	edge, ok := edges[[2]Pid{10, cid}]
+	if !ok {
+		t.Fatalf("no edge to the closed node, edges: %v", edges)
+	}
+	if edge[2].(int64) != int64(cid) || edge[4] != "closed:127.0.0.1:8080" {
+		t.Errorf("closed edge target %v, secondary stat %q", edge[2], edge[4])
+	}
+
+	node, ok := datas[cid]
+	if !ok {
+		t.Fatal("no closed node")
+	}
+	if !isClosed(Pid(node[0].(int64))) {
+		t.Errorf("node id %d is not a closed id", node[0])
+	}
+	if node[1] != "closed" || node[2] != "127.0.0.1:8080" || node[3] != "TCP:127.0.0.1:8080" {
+		t.Errorf("closed node labeled %q, %q, %q", node[1], node[2], node[3])
+	}
+	if node[4+closedArc] != 1.0 {
+		t.Errorf("closed node arcs %v", node[4:4+arcs])
+	}
+}
+
+func TestIdKinds(t *testing.T) {
+	for _, test := range []struct {
+		id     Pid
+		closed bool
+	}{
+		{-5, false},
+		{10, false},
+		{1 << 31, false},
+		{selfBase | 10, false},
+		{fileBase + 1, false},
+		{closedBase | 10, true},
+		{closedBase | 1<<31 - 1, true},
+	} {
+		if closed := isClosed(test.id); closed != test.closed {
+			t.Errorf("isClosed(%#x) = %t, want %t", test.id, closed, test.closed)
+		}
+		if pid := pidOf(test.id); (test.id < 0 || test.id >= selfBase) && pid != 0 {
+			t.Errorf("pidOf(%#x) = %d, want no pid", test.id, pid)
+		}
+		if test.id >= 1<<53 {
+			t.Errorf("id %#x exceeds the frontend's integer precision", test.id)
+		}
+	}
+}
+
+func TestExitedMidQuery(t *testing.T) {
+	tb := process.Table{
+		1: testProcess(1, 0, "init"),
+		10: testProcess(10, 1, "client",
+			testConnection("TCP", 10, "127.0.0.1:41000", 20, "127.0.0.1:8080"), // the server exited
+			testConnection("TCP", 10, "10.0.0.2:41001", -3, "10.0.0.8:443"),
+		),
+		30: testProcess(30, 25, "orphan"), // its parent exited before it was reparented
+	}
+
+	for _, model := range []queryModel{
+		{},
+		{Pid: 10},
+		{Pid: 20}, // the query's process exited
+		{Pid: 30},
+		{SeedHost: "10.0.0.8"},
+	} {
+		g := testQuery(model, dataSourceSettings{}).build(tb)
+		if len(g.nodes) == 0 {
+			t.Errorf("query %+v built no nodes", model)
+		}
+		if model.Pid != 10 {
+			continue
+		}
+		found := false
+		for _, n := range g.nodes {
+			found = found || isClosed(Pid(n[0].(int64)))
+		}
+		if !found {
+			t.Errorf("query %+v has no closed node for the exited server", model)
+		}
+	}
+}
+
+func TestAdoptOrphans(t *testing.T) {
+	tb := process.Table{
+		1:  testProcess(1, 0, "init"),
+		30: testProcess(30, 25, "orphan"),
+		31: testProcess(31, 30, "child"),
+	}
+	adopted := adoptOrphans(tb)
+	if adopted[30].Ppid != 1 || adopted[31].Ppid != 30 {
+		t.Errorf("orphan's parent is %d, its child's %d, want 1 and 30", adopted[30].Ppid, adopted[31].Ppid)
+	}
+	if tb[30].Ppid != 25 {
+		t.Error("the shared table's orphan was modified")
+	}
+	if g := testQuery(queryModel{TreeOnly: true}, dataSourceSettings{}).tree(tb); len(g.nodes) != 3 {
+		t.Errorf("tree has %d nodes, want 3", len(g.nodes))
+	}
+}
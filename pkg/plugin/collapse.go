@@ -0,0 +1,87 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/zosmac/gomon/process"
+)
+
+type (
+	// collapsed counts the processes and connections folded into a collapse root.
+	collapsed struct {
+		processes   int
+		connections int
+	}
+)
+
+// collapse folds the descendants of the query's collapse roots into their roots, re-homing their edges to the root.
+// It returns the folded processes mapped to their roots, and the counts of what each root represents.
+func (query Query) collapse(tb process.Table, itr process.Tree, edges map[[2]Pid][]any) (map[Pid]Pid, map[Pid]collapsed) {
+	folded := map[Pid]Pid{}
+	for _, root := range query.model.Collapse {
+		if st := itr.FindTree(root); st != nil {
+			for _, pid := range st[root].All() {
+				folded[pid] = root
+			}
+		}
+	}
+	if len(folded) == 0 {
+		return nil, nil
+	}
+
+	// a collapse root may itself be folded into an ancestor's collapse root
+	fold := func(pid Pid) Pid {
+		for {
+			root, ok := folded[pid]
+			if !ok {
+				return pid
+			}
+			pid = root
+		}
+	}
+	roots := map[Pid]collapsed{}
+	for pid := range folded {
+		folded[pid] = fold(pid)
+		c := roots[folded[pid]]
+		c.processes++
+		roots[folded[pid]] = c
+	}
+
+	for id, edge := range edges {
+		self, peer := fold(id[0]), fold(id[1])
+		if self == id[0] && peer == id[1] {
+			continue
+		}
+		delete(edges, id)
+		if self == peer { // internal to the collapsed subtree
+			continue
+		}
+
+		for _, pid := range []Pid{self, peer} {
+			if c, ok := roots[pid]; ok {
+				c.connections += len(edge) - connIndex
+				roots[pid] = c
+			}
+		}
+
+		nid := [2]Pid{self, peer}
+		if e, ok := edges[nid]; ok {
+			edges[nid] = append(e, edge[connIndex:]...)
+			continue
+		}
+		edge[0] = fmt.Sprintf("%d -> %d", self, peer)
+		edge[1] = int64(self)
+		edge[2] = int64(peer)
+		if self != id[0] {
+			edge[3] = tb[self].Shortname()
+		}
+		if peer != id[1] {
+			edge[4] = tb[peer].Shortname()
+		}
+		edges[nid] = edge
+	}
+
+	return folded, roots
+}
@@ -0,0 +1,51 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"hash/fnv"
+)
+
+var (
+	// nameColors is the palette for coloring processes by name. It avoids the colors of the arcs that identify the
+	// other node types.
+	nameColors = []string{
+		"#8c564b", // brown
+		"#f7b6d2", // pink
+		"#7f7f7f", // gray
+		"#bcbd22", // olive
+		"#d2b48c", // tan
+		"#800000", // maroon
+		"#708090", // slate
+		"#a0522d", // sienna
+		"#008080", // teal
+		"#bc8f8f", // rosy brown
+		"#bdb76b", // khaki
+		"#f5deb3", // wheat
+	}
+)
+
+// nameColor hashes a name to a color of the palette, so that every process of an executable shares its color.
+func nameColor(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return nameColors[h.Sum32()%uint32(len(nameColors))]
+}
+
+// colorByName appends the color field's value to each node of the graph, coloring the process nodes by name.
+// The node graph only draws a node's color if none of its arcs is set, so the process arc of a process node is
+// cleared, unless a warning splits its circle. Other nodes have no color and keep their arcs.
+func (query Query) colorByName(g *graph) {
+	if !query.model.ColorByName {
+		return
+	}
+	g.colorByName = true
+	for i, n := range g.nodes {
+		color := ""
+		if n[4+procArc] == 1.0 {
+			n[4+procArc] = 0.0
+			color = nameColor(n[1].(string))
+		}
+		g.nodes[i] = append(n, color)
+	}
+}
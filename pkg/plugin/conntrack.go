@@ -0,0 +1,33 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"github.com/zosmac/gomon/process"
+)
+
+type (
+	// flow holds the cumulative byte and packet counts of a conntrack flow, in both directions.
+	flow struct {
+		bytes   int64
+		packets int64
+	}
+
+	// flows holds the conntrack flows of sockets.
+	flows map[socketKey]flow
+)
+
+// edge sums the flows of the sockets connecting the nodes of an edge.
+// The counts are nil if none of the edge's sockets has a flow.
+func (f flows) edge(tb process.Table, id [2]Pid) (bytes, packets *int64) {
+	for _, key := range edgeSockets(tb, id) {
+		if fl, ok := f[key]; ok {
+			if bytes == nil {
+				bytes, packets = new(int64), new(int64)
+			}
+			*bytes += fl.bytes
+			*packets += fl.packets
+		}
+	}
+	return bytes, packets
+}
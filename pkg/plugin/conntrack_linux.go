@@ -0,0 +1,60 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// conntrack reads the flows of /proc/net/nf_conntrack, keyed by both the originating and the replying socket.
+// A flow's counters are only present if the kernel's conntrack accounting is enabled.
+func conntrack() flows {
+	f, err := os.Open("/proc/net/nf_conntrack")
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	fs := flows{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		// e.g. ipv4 2 tcp 6 431999 ESTABLISHED src=... dst=... sport=... dport=... packets=... bytes=... src=...
+		var tuples [2]map[string]string
+		t := -1
+		for _, field := range strings.Fields(sc.Text()) {
+			name, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			if name == "src" && t < 1 { // each direction's tuple starts with its source
+				t++
+				tuples[t] = map[string]string{}
+			}
+			if t >= 0 {
+				tuples[t][name] = value
+			}
+		}
+		if t < 1 || tuples[0]["bytes"] == "" {
+			continue
+		}
+
+		var fl flow
+		for _, tuple := range tuples {
+			bytes, _ := strconv.ParseInt(tuple["bytes"], 10, 64)
+			packets, _ := strconv.ParseInt(tuple["packets"], 10, 64)
+			fl.bytes += bytes
+			fl.packets += packets
+		}
+		orig := tuples[0]
+		src := normalAddress(net.JoinHostPort(orig["src"], orig["sport"]))
+		dst := normalAddress(net.JoinHostPort(orig["dst"], orig["dport"]))
+		fs[socketKey{src, dst}] = fl // originating socket
+		fs[socketKey{dst, src}] = fl // replying socket
+	}
+
+	return fs
+}
@@ -0,0 +1,10 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+//go:build !linux
+
+package plugin
+
+// conntrack flows are only read for Linux.
+func conntrack() flows {
+	return nil
+}
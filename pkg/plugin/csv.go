@@ -0,0 +1,87 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+)
+
+var (
+	// nodesHeader is the header row of the nodes CSV export.
+	nodesHeader = []string{"id", "title", "pid", "executable", "user", "arc type"}
+
+	// edgesHeader is the header row of the edges CSV export.
+	edgesHeader = []string{"id", "source", "target", "protocol", "relation"}
+
+	// userDetail is the index in a process node of its user.
+	userDetail = detailIndex("user")
+)
+
+// nodeType reports the path of the arc that identifies a node's type, which a warning shares.
+func nodeType(n []any) string {
+	for i, a := range arcFields {
+		if i != warnArc && n[4+i].(float64) != 0 {
+			return a.path
+		}
+	}
+	return arcFields[warnArc].path
+}
+
+// nodesCSV formats the nodes of a graph as CSV. The pid and executable are reported for process nodes,
+// whose name is the executable qualified by the pid.
+func nodesCSV(g graph) ([]byte, error) {
+	records := [][]string{nodesHeader}
+	for _, n := range g.nodes {
+		pid, executable, user := "", "", ""
+		typ := nodeType(n)
+		switch typ {
+		case arcFields[procArc].path, arcFields[transientArc].path:
+			executable = strings.TrimSuffix(n[3].(string), "["+n[2].(string)+"]")
+			fallthrough
+		case arcFields[threadArc].path:
+			pid = pidOf(Pid(n[0].(int64))).String()
+			user = n[userDetail].(string)
+		}
+		records = append(records, []string{
+			strconv.FormatInt(n[0].(int64), 10),
+			n[1].(string),
+			pid,
+			executable,
+			user,
+			typ,
+		})
+	}
+	return csvBody(records)
+}
+
+// edgesCSV formats the edges of a graph as CSV. The relation lists the edge's connections, one per line.
+func edgesCSV(g graph) ([]byte, error) {
+	records := [][]string{edgesHeader}
+	for _, e := range g.edges {
+		conns := make([]string, 0, len(e)-connIndex)
+		for _, conn := range e[connIndex:] {
+			conns = append(conns, conn.(string))
+		}
+		records = append(records, []string{
+			e[0].(string),
+			strconv.FormatInt(e[1].(int64), 10),
+			strconv.FormatInt(e[2].(int64), 10),
+			e[protocolDetail].(string),
+			strings.Join(conns, "\n"),
+		})
+	}
+	return csvBody(records)
+}
+
+// csvBody writes the records as CSV, quoting the fields that contain commas, quotes, or newlines.
+func csvBody(records [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -7,22 +7,61 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"runtime"
 	"strconv"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/zosmac/gocore"
-	"github.com/zosmac/gomon/process"
 )
 
 type (
+	// dataSourceSettings defines the configuration options of the datasource.
+	dataSourceSettings struct {
+		SnapshotRetention int               `json:"snapshotRetention"` // number of retained snapshots, 0 to disable
+		SnapshotInterval  int               `json:"snapshotInterval"`  // seconds between snapshots
+		HostnameTimeout   int               `json:"hostnameTimeout"`   // milliseconds to resolve a host's name
+		HostnameStyle     string            `json:"hostnameStyle"`     // host nodes' names: short, fqdn (default), or ip
+		Anonymize         bool              `json:"anonymize"`         // replace identifying names with opaque tokens
+		EdgeMainStat      string            `json:"edgeMainStat"`      // text/template of edges' main stat
+		EdgeSecondaryStat string            `json:"edgeSecondaryStat"` // text/template of edges' secondary stat
+		Services          map[string]string `json:"services"`          // service names by port, overriding the well known ports
+		EncryptedPorts    map[string]*bool  `json:"encryptedPorts"`    // encrypted (true), plaintext (false), or unknown (null) by port
+		Denylist          []string          `json:"denylist"`          // globs of executable names whose details are not reported
+		InternalNetworks  []string          `json:"internalNetworks"`  // CIDRs of addresses local to the host, e.g. container bridges
+		StreamMinInterval int               `json:"streamMinInterval"` // minimum seconds between a stream's graphs
+		StreamMaxInterval int               `json:"streamMaxInterval"` // maximum seconds between a stream's graphs
+		Warnings          []string          `json:"warnings"`          // heuristics flagging process nodes: root, reexec, fanout
+		FanOut            int               `json:"fanOut"`            // count of remote hosts that flags a process for fanout
+		GPUDevices        []string          `json:"gpuDevices"`        // globs of GPU and accelerator device paths
+		Profile           bool              `json:"profile"`           // record the durations of the phases of building graphs
+		Conntrack         bool              `json:"conntrack"`         // report edges' conntrack flow byte and packet counts
+		Groups            []processGroup    `json:"groups"`            // named groups of processes by executable name pattern
+		QueryTimeout      int               `json:"queryTimeout"`      // seconds to build a query's graph before reporting it partial
+		ReplayFile        string            `json:"replayFile"`        // captured process table to graph instead of the live system
+		RemoteURL         string            `json:"remoteURL"`         // table resource of a remote host's gomon datasource to graph instead
+		Transients        bool              `json:"transients"`        // observe short-lived processes from process events, requires elevated privileges
+		HideSelf          *bool             `json:"hideSelf"`          // exclude the plugin's process and its collector commands, default true
+
+		mainStat, secondaryStat *template.Template
+		internal                []*net.IPNet
+		remoteToken             string // bearer token of the remote URL, from the secure settings
+	}
+
 	// Instance of the datasource.
 	Instance struct {
-		ctx    context.Context
-		Health struct {
+		ctx       context.Context
+		cancel    context.CancelFunc
+		settings  dataSourceSettings
+		snapshots *snapshots
+		Health    struct {
 			Checks int `json:"checks"`
 		} `json:"health"`
 		Query struct {
@@ -39,45 +78,93 @@ type (
 	}
 )
 
-var (
-	instance Instance
-)
-
 func Factory(ctx context.Context) datasource.InstanceFactoryFunc {
 	gocore.Error("DataSourceInstanceFactory", nil).Info()
 
-	return func(ctx context.Context, settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+	return func(_ context.Context, settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
 		gocore.Error("create datasource instance", nil, map[string]string{
 			"id":       strconv.Itoa(int(settings.ID)),
 			"uid":      settings.UID,
 			"type":     settings.Type,
 			"name":     settings.Name,
 			"jsonData": string(settings.JSONData),
-		},
-			settings.DecryptedSecureJSONData,
-		).Info()
+		}).Info()
+
+		instance := &Instance{}
+		if len(settings.JSONData) > 0 {
+			if err := json.Unmarshal(settings.JSONData, &instance.settings); err != nil {
+				return nil, gocore.Error("datasource settings", err)
+			}
+		}
+		instance.settings.remoteToken = settings.DecryptedSecureJSONData["remoteToken"]
+		if err := instance.settings.parse(); err != nil {
+			return nil, gocore.Error("datasource settings", err)
+		}
 
-		instance.ctx = ctx
+		instance.ctx, instance.cancel = context.WithCancel(ctx)
+
+		if instance.settings.SnapshotRetention > 0 {
+			instance.snapshots = newSnapshots(
+				instance.settings.SnapshotRetention,
+				time.Duration(instance.settings.SnapshotInterval)*time.Second,
+			)
+			go instance.snapshots.record(instance.ctx, instance.settings)
+		}
+		if instance.settings.Transients {
+			go observeTransients(instance.ctx)
+		}
 
 		gocore.Error("datasource instance", nil, map[string]string{
 			"id": strconv.Itoa(int(settings.ID)),
 		}).Info()
 
-		return &instance, nil
+		return instance, nil
+	}
+}
+
+// parse validates the settings and prepares their templates, networks, and groups.
+func (settings *dataSourceSettings) parse() error {
+	if err := settings.parseTemplates(); err != nil {
+		return fmt.Errorf("edge label template: %w", err)
+	}
+	if err := settings.validateDenylist(); err != nil {
+		return err
+	}
+	if err := settings.parseNetworks(); err != nil {
+		return err
+	}
+	if err := settings.validateHostnameStyle(); err != nil {
+		return err
+	}
+	if err := settings.validateWarnings(); err != nil {
+		return err
 	}
+	if err := settings.validateGPUDevices(); err != nil {
+		return err
+	}
+	if err := settings.parseGroups(); err != nil {
+		return err
+	}
+	if err := settings.validateRemote(); err != nil {
+		return err
+	}
+	return settings.validateReplay()
 }
 
-// Dispose run when instance cleaned up.
+// Dispose run when instance cleaned up. The instance manager disposes of an instance after creating its
+// replacement, so only this instance's goroutines are stopped.
 func (instance *Instance) Dispose() {
 	gocore.Error("Dispose", nil, map[string]string{
 		"datasource": fmt.Sprint(*instance),
 	}).Info()
 
-	*instance = Instance{}
+	if instance.cancel != nil {
+		instance.cancel()
+	}
 }
 
 // CheckHealth run when "save and test" of data source run.
-func (instance *Instance) CheckHealth(_ context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+func (instance *Instance) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
 	defer func() {
 		if r := recover(); r != nil {
 			buf := make([]byte, 4096)
@@ -100,6 +187,20 @@ func (instance *Instance) CheckHealth(_ context.Context, req *backend.CheckHealt
 
 	status := backend.HealthStatusOk
 	message := "instance healthy, see log for details"
+	if instance.settings.RemoteURL != "" {
+		if _, err := fetchTable(ctx, instance.settings); err != nil {
+			status = backend.HealthStatusError
+			message = "remote host not reachable: " + err.Error()
+		} else {
+			message = "remote host reachable at " + instance.settings.RemoteURL
+		}
+	} else if !checkReady() {
+		status = backend.HealthStatusError
+		message = "instance not ready, the collector is warming up"
+	} else if user, ok := restricted(lockedTable(false)); ok {
+		message = "instance healthy, but only the processes of user " + user +
+			" are visible, run with elevated privileges for a full view"
+	}
 
 	gocore.Error("CheckHealth results", nil, map[string]string{
 		"status":  status.String(),
@@ -118,18 +219,26 @@ func (instance *Instance) CheckHealth(_ context.Context, req *backend.CheckHealt
 }
 
 // CallResource of data source.
-func (instance *Instance) CallResource(_ context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+func (instance *Instance) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
 	gocore.Error("CallResource", nil, map[string]string{
 		"instance": fmt.Sprint(*instance),
 		"request":  fmt.Sprint(*req),
 		"sender":   fmt.Sprint(sender),
 	}).Info()
 
-	return nil
+	handler, ok := resources[[2]string{req.Method, strings.Trim(req.Path, "/")}]
+	if !ok {
+		return sender.Send(&backend.CallResourceResponse{
+			Status: http.StatusNotFound,
+			Body:   []byte("resource not found: " + req.Method + " " + req.Path),
+		})
+	}
+
+	return sender.Send(handler(instance, ctx, req))
 }
 
 // QueryData handler for data source.
-func (instance *Instance) QueryData(_ context.Context, req *backend.QueryDataRequest) (resp *backend.QueryDataResponse, err error) {
+func (instance *Instance) QueryData(ctx context.Context, req *backend.QueryDataRequest) (resp *backend.QueryDataResponse, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			buf := make([]byte, 4096)
@@ -150,19 +259,21 @@ func (instance *Instance) QueryData(_ context.Context, req *backend.QueryDataReq
 
 	instance.Query.Requests += 1
 	resp = backend.NewQueryDataResponse()
+	warm := !instance.settings.live() || awaitReady(ctx)
 
 	for _, query := range req.Queries {
 		instance.Query.Queries += 1
-		q := struct {
-			Pid process.Pid `json:"pid"`
-		}{}
-		if err = json.Unmarshal(query.JSON, &q); err != nil {
-			resp.Responses[query.RefID] = backend.DataResponse{Error: err}
+		q, err := parseQuery(query.JSON)
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
 			continue
 		}
 
 		to := time.Now()
 		from := to.Add(-5 * time.Minute)
+		if !query.TimeRange.To.IsZero() {
+			from, to = query.TimeRange.From, query.TimeRange.To
+		}
 
 		gocore.Error("Query", nil, map[string]string{
 			"pid":  q.Pid.String(),
@@ -177,7 +288,46 @@ func (instance *Instance) QueryData(_ context.Context, req *backend.QueryDataReq
 			"now",
 		)
 
-		resp.Responses[query.RefID] = Nodegraph(link, q.Pid)
+		if !warm {
+			resp.Responses[query.RefID] = backend.ErrDataResponse(backend.Status(http.StatusServiceUnavailable),
+				"collector warming up, retry shortly")
+			continue
+		}
+
+		// for a window, report the union of the snapshots within the time range
+		if q.Window {
+			if instance.snapshots == nil {
+				resp.Responses[query.RefID] = backend.ErrDataResponse(backend.StatusBadRequest,
+					"window queries require snapshot retention")
+				continue
+			}
+			g := instance.snapshots.union(ctx, q, instance.settings, from, to)
+			if instance.settings.Anonymize {
+				g = anonymize(g)
+			}
+			resp.Responses[query.RefID] = backend.DataResponse{Frames: nodeFrames(link, g, q.Streaming)}
+			continue
+		}
+
+		// for a graph at a past time, report the graph of the snapshot closest to that time
+		if instance.snapshots != nil && time.Since(to) > instance.snapshots.interval {
+			if snap, ok := instance.snapshots.closest(to); ok {
+				g := snap.build(ctx, q, instance.settings)
+				if instance.settings.Anonymize {
+					g = anonymize(g)
+				}
+				if age := to.Sub(g.timestamp).Abs(); age > instance.snapshots.interval {
+					g = g.withNotice(data.NoticeSeverityInfo, "graph is a snapshot %s from the requested time",
+						age.Round(time.Second))
+				}
+				resp.Responses[query.RefID] = backend.DataResponse{Frames: nodeFrames(link, g, q.Streaming)}
+				continue
+			}
+		}
+
+		qctx, cancel := instance.settings.queryContext(ctx)
+		resp.Responses[query.RefID] = Nodegraph(qctx, link, q, instance.settings)
+		cancel()
 	}
 
 	return resp, nil
@@ -0,0 +1,34 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestFactoryDispose(t *testing.T) {
+	factory := Factory(context.Background())
+	settings := backend.DataSourceInstanceSettings{JSONData: []byte(`{"anonymize":true}`)}
+	old, err := factory(context.Background(), settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacement, err := factory(context.Background(), settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old == replacement {
+		t.Fatal("factory returned the same instance twice")
+	}
+
+	old.(*Instance).Dispose() // the instance manager disposes of the old instance after creating its replacement
+	if old.(*Instance).ctx.Err() == nil {
+		t.Error("disposed instance's context is not cancelled")
+	}
+	if r := replacement.(*Instance); r.ctx.Err() != nil || !r.settings.Anonymize {
+		t.Errorf("replacement instance's context error %v, anonymize %t", r.ctx.Err(), r.settings.Anonymize)
+	}
+}
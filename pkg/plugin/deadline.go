@@ -0,0 +1,48 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// queryContext bounds a query's context by the settings' query timeout, if configured.
+func (settings dataSourceSettings) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if settings.QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(settings.QueryTimeout)*time.Second)
+}
+
+// expired reports whether the query's context is done, e.g. its deadline passed, noting that the graph is partial.
+func (query Query) expired() bool {
+	if query.ctx.Err() == nil {
+		return false
+	}
+	query.notices.add(data.NoticeSeverityWarning, "graph is partial, the query timed out or was cancelled before the graph was built")
+	return true
+}
+
+// dropDangling removes the edges of a partial graph to nodes it lacks, and the host and data nodes left without edges.
+func dropDangling(g *graph) {
+	ids := map[int64]bool{}
+	for _, n := range g.nodes {
+		ids[n[0].(int64)] = true
+	}
+	g.edges = slices.DeleteFunc(g.edges, func(e []any) bool {
+		return !ids[e[1].(int64)] || !ids[e[2].(int64)]
+	})
+
+	referenced := map[int64]bool{}
+	for _, e := range g.edges {
+		referenced[e[1].(int64)] = true
+		referenced[e[2].(int64)] = true
+	}
+	g.nodes = slices.DeleteFunc(g.nodes, func(n []any) bool {
+		return !isProcess(Pid(n[0].(int64))) && !referenced[n[0].(int64)]
+	})
+}
@@ -0,0 +1,49 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCancelledQuery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	query := testQuery(queryModel{}, dataSourceSettings{})
+	query.ctx = ctx
+
+	g := query.selected(snapshotTable())
+	partial := false
+	for _, notice := range g.notices {
+		partial = partial || strings.HasPrefix(notice.Text, "graph is partial")
+	}
+	if !partial {
+		t.Errorf("cancelled query's notices %v do not report a partial graph", g.notices)
+	}
+	ids := map[int64]bool{}
+	for _, n := range g.nodes {
+		ids[n[0].(int64)] = true
+	}
+	for _, e := range g.edges {
+		if !ids[e[1].(int64)] || !ids[e[2].(int64)] {
+			t.Errorf("edge %v of the partial graph dangles", e[0])
+		}
+	}
+}
+
+func TestQueryContext(t *testing.T) {
+	ctx, cancel := dataSourceSettings{QueryTimeout: 5}.queryContext(context.Background())
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > 5*time.Second {
+		t.Errorf("query context deadline %v, %t, want within 5s", deadline, ok)
+	}
+
+	ctx, cancel = dataSourceSettings{}.queryContext(context.Background())
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("query context without a timeout setting has a deadline")
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/zosmac/gomon/process"
+)
+
+// validateDenylist checks that the settings' denylist entries are valid globs.
+func (settings dataSourceSettings) validateDenylist() error {
+	for i, pattern := range settings.Denylist {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("denylist[%d] %q is not a valid pattern: %w", i, pattern, err)
+		}
+	}
+	return nil
+}
+
+// denied reports whether a process' executable name matches the denylist, so the plugin reads nothing more of it.
+// Connections to a denied process may still be reported from its peers.
+func (query Query) denied(p *process.Process) bool {
+	return query.settings.denied(p)
+}
+
+// denied reports whether a process' executable name matches the settings' denylist.
+func (settings dataSourceSettings) denied(p *process.Process) bool {
+	if p == nil {
+		return false
+	}
+	for _, pattern := range settings.Denylist {
+		if ok, _ := path.Match(pattern, p.Id.Name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, filepath.Base(p.Executable)); ok && p.Executable != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/zosmac/gomon/process"
+)
+
+func TestDeniedChanges(t *testing.T) {
+	settings := dataSourceSettings{Denylist: []string{"vault*"}}
+	vault := testProcess(20, 1, "vault",
+		testConnection("TCP", 20, "127.0.0.1:8200", 30, "127.0.0.1:51234"),
+	)
+	client := testProcess(30, 1, "client")
+	tb := process.Table{vault.Pid: vault, client.Pid: client}
+
+	if !settings.denied(vault) || settings.denied(client) {
+		t.Fatal("denylist does not match the vault process alone")
+	}
+
+	c := diffTables(newTableState(process.Table{}, settings), newTableState(tb, settings))
+	for _, p := range c.Appeared {
+		if p.Pid == vault.Pid && p.Executable != "" {
+			t.Errorf("denied process' executable %q is reported", p.Executable)
+		}
+		if p.Pid == client.Pid && p.Executable != client.Executable {
+			t.Errorf("process' executable %q, want %q", p.Executable, client.Executable)
+		}
+	}
+	if len(c.Opened) != 1 || c.Opened[0].Executable != "" {
+		t.Errorf("denied process' connection reports its executable: %+v", c.Opened)
+	}
+}
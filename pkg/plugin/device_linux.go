@@ -0,0 +1,49 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+var (
+	// deviceNames names the classes of well known device major numbers, per the kernel's devices.txt.
+	deviceNames = map[uint64]string{
+		1:   "memory",
+		3:   "ide disk",
+		4:   "tty",
+		5:   "tty",
+		7:   "loop",
+		8:   "scsi disk",
+		9:   "md raid",
+		10:  "misc",
+		11:  "cdrom",
+		13:  "input",
+		29:  "framebuffer",
+		136: "pty",
+		180: "usb",
+		188: "usb serial",
+		226: "dri",
+		252: "device-mapper",
+		253: "device-mapper",
+		254: "rtc",
+		259: "nvme disk",
+	}
+)
+
+// device reports the major:minor number of a block or character device, with the class of its major number.
+func device(path string) string {
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return ""
+	}
+	major := uint64(unix.Major(uint64(st.Rdev)))
+	minor := uint64(unix.Minor(uint64(st.Rdev)))
+	name := strconv.FormatUint(major, 10) + ":" + strconv.FormatUint(minor, 10)
+	if class, ok := deviceNames[major]; ok {
+		name += " " + class
+	}
+	return name
+}
@@ -0,0 +1,16 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"testing"
+)
+
+func TestDevice(t *testing.T) {
+	if dev := device("/dev/null"); dev != "1:3 memory" {
+		t.Errorf("device of /dev/null is %q, want %q", dev, "1:3 memory")
+	}
+	if dev := device("/nonexistent"); dev != "" {
+		t.Errorf("device of a missing path is %q, want none", dev)
+	}
+}
@@ -0,0 +1,10 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+//go:build !linux
+
+package plugin
+
+// device is only determined for Linux devices.
+func device(string) string {
+	return ""
+}
@@ -0,0 +1,61 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"strings"
+	"text/template"
+)
+
+type (
+	// edgeLabel provides the fields of an edge to the edge label templates.
+	edgeLabel struct {
+		Type     string // connection type, or parent or thread for process tree edges
+		SelfName string // name of the edge's source node
+		PeerName string // name of the edge's target node
+		SelfPid  Pid    // id of the edge's source node
+		PeerPid  Pid    // id of the edge's target node
+	}
+)
+
+// parseTemplates parses the settings' edge label templates, reporting an invalid template.
+func (settings *dataSourceSettings) parseTemplates() error {
+	var err error
+	if settings.EdgeMainStat != "" {
+		if settings.mainStat, err = template.New("edgeMainStat").Parse(settings.EdgeMainStat); err != nil {
+			return err
+		}
+	}
+	if settings.EdgeSecondaryStat != "" {
+		if settings.secondaryStat, err = template.New("edgeSecondaryStat").Parse(settings.EdgeSecondaryStat); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// label formats the main and secondary stats of an edge, initially its source and target names, from the settings' templates.
+// The names remain if no template is set, or if the template fails.
+func (query Query) label(edge []any) {
+	label := edgeLabel{
+		Type:     edge[protocolDetail].(string),
+		SelfName: edge[3].(string),
+		PeerName: edge[4].(string),
+		SelfPid:  Pid(edge[1].(int64)),
+		PeerPid:  Pid(edge[2].(int64)),
+	}
+	edge[3] = execute(query.settings.mainStat, label, label.SelfName)
+	edge[4] = execute(query.settings.secondaryStat, label, label.PeerName)
+}
+
+// execute applies a template to an edge label.
+func execute(tmpl *template.Template, label edgeLabel, text string) string {
+	if tmpl == nil {
+		return text
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, label); err != nil {
+		return text
+	}
+	return sb.String()
+}
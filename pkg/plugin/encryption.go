@@ -0,0 +1,44 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+var (
+	// encryptedPorts classifies the well known ports whose services are encrypted, or plaintext. Ports whose
+	// services may or may not negotiate TLS, e.g. smtp or redis, are left unclassified.
+	encryptedPorts = map[string]bool{
+		"21":   false, // ftp
+		"22":   true,  // ssh
+		"23":   false, // telnet
+		"80":   false, // http
+		"110":  false, // pop3
+		"143":  false, // imap
+		"389":  false, // ldap
+		"443":  true,  // https
+		"465":  true,  // smtps
+		"636":  true,  // ldaps
+		"993":  true,  // imaps
+		"995":  true,  // pop3s
+		"5671": true,  // amqps
+		"6443": true,  // kubernetes
+		"8080": false, // http-alt
+		"8443": true,  // https-alt
+	}
+)
+
+// encrypted classifies a network connection as encrypted or plaintext by the first of its ports with a known
+// service, preferring the datasource's configured classification to the well known ports'. A configured null
+// unclassifies a port. The classification of a connection with no known port is null.
+func (query Query) encrypted(ports ...string) *bool {
+	for _, port := range ports {
+		if port == "" {
+			continue
+		}
+		if enc, ok := query.settings.EncryptedPorts[port]; ok {
+			return enc
+		}
+		if enc, ok := encryptedPorts[port]; ok {
+			return &enc
+		}
+	}
+	return nil
+}
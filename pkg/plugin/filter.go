@@ -0,0 +1,349 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/zosmac/gomon/process"
+)
+
+var (
+	// fileCategories are the categories of data nodes that the query's file types select.
+	fileCategories = []string{"sockets", "pipes", "regular", "devices", "shared-memory"}
+)
+
+// filter applies the query's filters to the graph's edges, and removes the host and data nodes left without edges.
+// It returns the processes of the tree that the filters remove from the graph.
+func (query Query) filter(
+	tb process.Table,
+	itr process.Tree,
+	hosts, datas map[Pid][]any,
+	edges map[[2]Pid][]any,
+) map[Pid]struct{} {
+	dropped := map[Pid]struct{}{}
+
+	// a process may exit while its graph is built, so connect its live peers to a closed node in its place
+	if exited := query.closedPeers(tb, datas, edges); exited > 0 {
+		query.notices.add(data.NoticeSeverityInfo, "%d processes exited while the graph was built", exited)
+	}
+
+	// excluded processes are dropped along with their edges, regardless of the other filters
+	for _, pid := range query.model.ExcludePids {
+		dropped[pid] = struct{}{}
+	}
+	if query.settings.hideSelf() && query.live { // a replayed table's pids are not this system's
+		for pid := range ownProcesses(tb) {
+			dropped[pid] = struct{}{}
+		}
+	}
+	for id := range edges {
+		_, self := dropped[id[0]]
+		_, peer := dropped[id[1]]
+		if self || peer {
+			delete(edges, id)
+		}
+	}
+
+	query.connectionFilters(edges)
+
+	if query.model.FileFilter != "" {
+		for pid, node := range datas {
+			if typ := node[1].(string); (typ == "REG" || typ == "DIR") && !query.fileMatch(node[2].(string)) {
+				delete(datas, pid)
+			}
+		}
+		for id := range edges {
+			if _, ok := datas[id[1]]; !ok && isData(id[1]) {
+				delete(edges, id)
+			}
+		}
+	}
+
+	if len(query.model.FileTypes) > 0 {
+		for pid, node := range datas {
+			if isClosed(pid) {
+				continue
+			}
+			if !slices.Contains(query.model.FileTypes, fileCategory(node[1].(string), node[2].(string))) {
+				delete(datas, pid)
+			}
+		}
+		for id := range edges {
+			if _, ok := datas[id[1]]; !ok && isData(id[1]) {
+				delete(edges, id)
+			}
+		}
+	}
+
+	if query.model.RemoteOnly {
+		remote := map[Pid]struct{}{}
+		if query.model.Pid > 0 {
+			remote[query.model.Pid] = struct{}{}
+		}
+		for _, pid := range itr.All() {
+			if tb[pid] == nil {
+				continue
+			}
+			for _, conn := range tb[pid].Connections {
+				if conn.Peer.Pid < 0 && nodeArc(conn) == hostArc && !query.internal(conn) {
+					remote[pid] = struct{}{}
+					break
+				}
+			}
+		}
+		query.retain(tb, itr, edges, withAncestors(tb, remote), dropped)
+	}
+
+	if query.model.PeerHost != "" {
+		query.retain(tb, itr, edges, withAncestors(tb, query.peerHosts(tb, itr, query.model.PeerHost)), dropped)
+	}
+
+	if query.model.Unit != "" && !query.live {
+		query.notices.add(data.NoticeSeverityWarning, "unit ignored, a replayed table records no units")
+	} else if query.model.Unit != "" {
+		units := map[Pid]struct{}{}
+		for _, pid := range itr.All() {
+			if ok, _ := path.Match(query.model.Unit, unit(pid)); ok {
+				units[pid] = struct{}{}
+			}
+		}
+		// include the peers of the unit's processes, but not their children
+		keep := maps.Clone(units)
+		for id, edge := range edges {
+			_, self := units[id[0]]
+			_, peer := units[id[1]]
+			if self && !peer && isProcess(id[1]) && !parentEdge(edge) {
+				keep[id[1]] = struct{}{}
+			} else if peer && !self && isProcess(id[0]) && !parentEdge(edge) {
+				keep[id[0]] = struct{}{}
+			}
+		}
+		query.retain(tb, itr, edges, withAncestors(tb, keep), dropped)
+	}
+
+	if query.model.Depth != nil {
+		// the selected process, or else the roots of the tree, and their descendants to the depth,
+		// with their ancestors and peers
+		family := map[Pid]struct{}{}
+		if query.model.Pid > 0 {
+			family[query.model.Pid] = struct{}{}
+			if tr := itr.FindTree(query.model.Pid); tr != nil {
+				for depth, pid := range tr[query.model.Pid].All() {
+					if depth < *query.model.Depth { // depth 0 are the selected process' children
+						family[pid] = struct{}{}
+					}
+				}
+			}
+		} else {
+			for depth, pid := range itr.All() {
+				if depth <= *query.model.Depth { // depth 0 are the roots
+					family[pid] = struct{}{}
+				}
+			}
+		}
+		keep := maps.Clone(family)
+		for id, edge := range edges {
+			_, self := family[id[0]]
+			_, peer := family[id[1]]
+			if self && isProcess(id[1]) && !parentEdge(edge) {
+				keep[id[1]] = struct{}{}
+			} else if peer && isProcess(id[0]) && !parentEdge(edge) {
+				keep[id[0]] = struct{}{}
+			}
+		}
+		query.retain(tb, itr, edges, withAncestors(tb, keep), dropped)
+	}
+
+	if query.model.HideParentEdges {
+		query.filterConnections(edges, func(id [2]Pid, conn string) bool {
+			return query.connectionType(id, conn) != "parent"
+		})
+		// processes left without edges are orphaned, so drop them
+		connected := map[Pid]struct{}{}
+		if query.model.Pid > 0 {
+			connected[query.model.Pid] = struct{}{}
+		}
+		for id := range edges {
+			connected[id[0]] = struct{}{}
+			connected[id[1]] = struct{}{}
+		}
+		query.retain(tb, itr, edges, connected, dropped)
+	}
+
+	if query.model.HideGroupEdges {
+		query.hideGroupEdges(tb, edges)
+	}
+
+	if query.model.CrossBoundaryOnly {
+		query.crossBoundary(tb, itr, edges, dropped)
+	}
+
+	pruneNodes(hosts, edges)
+	pruneNodes(datas, edges)
+
+	return dropped
+}
+
+// retain records the processes of the tree not kept as dropped, and removes the edges of dropped processes.
+// Pinned processes, their ancestors, and the processes they connect to are always kept.
+func (query Query) retain(tb process.Table, itr process.Tree, edges map[[2]Pid][]any, keep, dropped map[Pid]struct{}) {
+	pinned := map[Pid]struct{}{}
+	for _, pid := range query.model.PinPids {
+		pinned[pid] = struct{}{}
+	}
+	for id := range edges {
+		_, self := pinned[id[0]]
+		_, peer := pinned[id[1]]
+		if self && isProcess(id[1]) {
+			keep[id[1]] = struct{}{}
+		} else if peer && isProcess(id[0]) {
+			keep[id[0]] = struct{}{}
+		}
+	}
+	maps.Copy(keep, withAncestors(tb, pinned))
+
+	for _, pid := range itr.All() {
+		if _, ok := keep[pid]; !ok {
+			dropped[pid] = struct{}{}
+		}
+	}
+	for id := range edges {
+		_, self := dropped[id[0]]
+		_, peer := dropped[id[1]]
+		if self || peer {
+			delete(edges, id)
+		}
+	}
+}
+
+// withAncestors adds the ancestors of the processes to the set of processes.
+func withAncestors(tb process.Table, pids map[Pid]struct{}) map[Pid]struct{} {
+	for pid := range pids {
+		for p := tb[pid]; p != nil && p.Ppid > 0; p = tb[p.Ppid] {
+			if _, ok := pids[p.Ppid]; ok {
+				break
+			}
+			pids[p.Ppid] = struct{}{}
+		}
+	}
+	return pids
+}
+
+// filterConnections removes the connections from edges that keep rejects, and removes edges left without connections.
+func (query Query) filterConnections(edges map[[2]Pid][]any, keep func(id [2]Pid, conn string) bool) {
+	for id, edge := range edges {
+		conns := slices.DeleteFunc(edge[connIndex:], func(conn any) bool {
+			return !keep(id, conn.(string))
+		})
+		if len(conns) == 0 {
+			delete(edges, id)
+		} else {
+			edges[id] = edge[:connIndex+len(conns)]
+		}
+	}
+}
+
+// connectionFilters applies the query's filters of individual connections to the graph's edges: the protocols,
+// hiding loopback connections, and for intra-process IPC, whose satellite node has no file type, the file types.
+// An edge left without connections is removed.
+func (query Query) connectionFilters(edges map[[2]Pid][]any) {
+	if len(query.model.Protocols) > 0 {
+		query.filterConnections(edges, func(id [2]Pid, conn string) bool {
+			typ := query.connectionType(id, conn)
+			return typ == "parent" || query.protocol(typ)
+		})
+	}
+
+	if query.model.HideLoopback {
+		query.hideLoopback(edges)
+	}
+
+	if len(query.model.FileTypes) > 0 {
+		query.filterConnections(edges, func(id [2]Pid, conn string) bool {
+			if kind(id[1]) != selfKind { // data nodes are filtered by their file type
+				return true
+			}
+			category := fileCategory(query.connectionType(id, conn), "")
+			return category == "other" || slices.Contains(query.model.FileTypes, category)
+		})
+	}
+}
+
+// protocol reports whether the query's protocols, if any, include a connection type.
+func (query Query) protocol(typ string) bool {
+	return len(query.model.Protocols) == 0 || slices.ContainsFunc(query.model.Protocols, func(protocol string) bool {
+		return strings.EqualFold(protocol, typ)
+	})
+}
+
+// fileMatch reports whether a file's path matches the query's file filter, either as a glob or as a path prefix.
+func (query Query) fileMatch(name string) bool {
+	if ok, _ := filepath.Match(query.model.FileFilter, name); ok {
+		return true
+	}
+	prefix := strings.TrimSuffix(query.model.FileFilter, "/")
+	return name == prefix || strings.HasPrefix(name, prefix+"/")
+}
+
+// fileCategory groups the type of a data connection's file into a category for the query's file types.
+// A type in no category is reported as other.
+func fileCategory(typ, name string) string {
+	switch typ {
+	case "PSXSHM", "PSXSEM":
+		return "shared-memory"
+	case "REG", "DIR":
+		if strings.HasPrefix(name, "/dev/shm/") {
+			return "shared-memory"
+		}
+		return "regular"
+	case "unix", "sock", "netlink", "systm", "ndrv":
+		return "sockets"
+	case "PIPE", "FIFO":
+		return "pipes"
+	case "CHR", "BLK":
+		return "devices"
+	}
+	return "other"
+}
+
+// parentEdge reports whether an edge only connects a parent process with its child.
+func parentEdge(edge []any) bool {
+	for _, conn := range edge[connIndex:] {
+		if !strings.HasPrefix(conn.(string), "parent:") {
+			return false
+		}
+	}
+	return true
+}
+
+// connectionType extracts the connection type from an edge's connection description.
+func (query Query) connectionType(id [2]Pid, conn string) string {
+	if strings.HasPrefix(conn, "parent:") {
+		return "parent"
+	}
+	if isData(id[1]) { // data connection described as self -> type:peer
+		_, conn, _ = strings.Cut(conn, query.Arrow())
+	}
+	typ, _, _ := strings.Cut(conn, ":")
+	return typ
+}
+
+// pruneNodes removes the host or data nodes that no edge references.
+func pruneNodes(nodes map[Pid][]any, edges map[[2]Pid][]any) {
+	referenced := map[Pid]struct{}{}
+	for id := range edges {
+		referenced[id[0]] = struct{}{}
+		referenced[id[1]] = struct{}{}
+	}
+	for pid := range nodes {
+		if _, ok := referenced[pid]; !ok {
+			delete(nodes, pid)
+		}
+	}
+}
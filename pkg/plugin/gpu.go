@@ -0,0 +1,42 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/zosmac/gomon/process"
+)
+
+var (
+	// defaultGPUDevices are the globs of GPU and accelerator device paths, if not configured.
+	defaultGPUDevices = []string{"/dev/nvidia*", "/dev/dri/*", "/dev/kfd", "/dev/accel/*"}
+)
+
+// validateGPUDevices checks that the settings' GPU device entries are valid globs.
+func (settings dataSourceSettings) validateGPUDevices() error {
+	for i, pattern := range settings.GPUDevices {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("gpuDevices[%d] %q is not a valid pattern: %w", i, pattern, err)
+		}
+	}
+	return nil
+}
+
+// isGPU reports whether a data connection is to a GPU or accelerator device.
+func (query Query) isGPU(conn process.Connection) bool {
+	if conn.Type != "CHR" {
+		return false
+	}
+	patterns := query.settings.GPUDevices
+	if len(patterns) == 0 {
+		patterns = defaultGPUDevices
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, conn.Peer.Name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/zosmac/gomon/process"
+)
+
+type (
+	// processGroup names the processes whose executable name matches a regular expression, e.g. an application tier.
+	processGroup struct {
+		Name    string `json:"name"`
+		Pattern string `json:"pattern"`
+
+		regex *regexp.Regexp
+	}
+)
+
+// parseGroups compiles the settings' process group patterns, reporting an invalid pattern.
+func (settings *dataSourceSettings) parseGroups() error {
+	for i, group := range settings.Groups {
+		if group.Name == "" {
+			return fmt.Errorf("groups[%d] has no name", i)
+		}
+		regex, err := regexp.Compile(group.Pattern)
+		if err != nil {
+			return fmt.Errorf("groups[%d] %q pattern %q is not valid: %w", i, group.Name, group.Pattern, err)
+		}
+		settings.Groups[i].regex = regex
+	}
+	return nil
+}
+
+// group names the first of the settings' process groups whose pattern matches a process' name or executable.
+// A process matching no group is ungrouped.
+func (query Query) group(p *process.Process) string {
+	for _, group := range query.settings.Groups {
+		if group.regex == nil {
+			continue
+		}
+		if group.regex.MatchString(p.Id.Name) ||
+			p.Executable != "" && group.regex.MatchString(filepath.Base(p.Executable)) {
+			return group.Name
+		}
+	}
+	return ""
+}
+
+// hideGroupEdges removes the edges between the processes of a group.
+func (query Query) hideGroupEdges(tb process.Table, edges map[[2]Pid][]any) {
+	for id := range edges {
+		if !isProcess(id[0]) || !isProcess(id[1]) || tb[id[0]] == nil || tb[id[1]] == nil {
+			continue
+		}
+		if group := query.group(tb[id[0]]); group != "" && group == query.group(tb[id[1]]) {
+			delete(edges, id)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"context"
+
+	"github.com/zosmac/gomon/process"
+)
+
+// testQuery creates a query as buildGraph does, without sampling the system's sockets.
+func testQuery(model queryModel, settings dataSourceSettings) Query {
+	return Query{
+		ctx:        context.Background(),
+		model:      model,
+		settings:   settings,
+		containers: map[Pid]string{},
+		notices:    &notices{},
+		modes:      map[Pid]map[string]string{},
+	}
+}
+
+// testProcess creates a process of a test table.
+func testProcess(pid, ppid Pid, name string, conns ...process.Connection) *process.Process {
+	p := &process.Process{}
+	p.Pid = pid
+	p.Ppid = ppid
+	p.Id.Name = name
+	p.Executable = "/usr/bin/" + name
+	p.Username = "user"
+	p.Connections = conns
+	return p
+}
+
+// testConnection creates a connection of a test process.
+func testConnection(typ string, self Pid, selfName string, peer Pid, peerName string) process.Connection {
+	return process.Connection{
+		Type: typ,
+		Self: process.Endpoint{Name: selfName, Pid: self},
+		Peer: process.Endpoint{Name: peerName, Pid: peer},
+	}
+}
+
+// nodeId recovers the pid from the id of a process node, leaving the ids of the other nodes as they are.
+func nodeId(id Pid) Pid {
+	if isProcess(id) {
+		return pidOf(id)
+	}
+	return id
+}
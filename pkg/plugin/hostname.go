@@ -0,0 +1,147 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/zosmac/gocore"
+	"github.com/zosmac/gomon/process"
+)
+
+const (
+	// defaultHostnameTimeout limits the time to resolve a host's name if not configured.
+	defaultHostnameTimeout = time.Second
+)
+
+// validateHostnameStyle checks that the settings' hostname style is short, fqdn, or ip.
+func (settings dataSourceSettings) validateHostnameStyle() error {
+	switch settings.HostnameStyle {
+	case "", "short", "fqdn", "ip":
+		return nil
+	}
+	return fmt.Errorf("hostnameStyle %q is not one of short, fqdn, or ip", settings.HostnameStyle)
+}
+
+// styled formats a resolved host name in the settings' hostname style: the first label of the name for short,
+// or the full name for fqdn, the default. An unresolved address is not shortened.
+func (settings dataSourceSettings) styled(name string) string {
+	if settings.HostnameStyle != "short" || net.ParseIP(name) != nil {
+		return name
+	}
+	short, _, _ := strings.Cut(name, ".")
+	return short
+}
+
+// hostname resolves the name of a host address, reporting the address if not resolved before the timeout.
+func hostname(addr string, timeout time.Duration) (string, bool) {
+	ch := make(chan string, 1)
+	go func() {
+		ch <- gocore.Hostname(addr)
+	}()
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case name := <-ch:
+		return name, true
+	case <-t.C:
+		return addr, false
+	}
+}
+
+// hostnameTimeout returns the configured limit to resolve a host's name.
+func (query Query) hostnameTimeout() time.Duration {
+	if query.settings.HostnameTimeout > 0 {
+		return time.Duration(query.settings.HostnameTimeout) * time.Millisecond
+	}
+	return defaultHostnameTimeout
+}
+
+// resolveHosts resolves the names of the host nodes concurrently, setting each node's secondary stat.
+// For the ip hostname style, the nodes keep their addresses.
+func (query Query) resolveHosts(hosts map[Pid][]any) {
+	if query.settings.HostnameStyle == "ip" {
+		return
+	}
+	timeout := query.hostnameTimeout()
+
+	var wg sync.WaitGroup
+	var failed atomic.Int64
+	for _, node := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var ok bool
+			var name string
+			if name, ok = hostname(node[3].(string), timeout); !ok {
+				failed.Add(1)
+			}
+			node[2] = query.settings.styled(name)
+			node[hostDetail] = node[2]
+		}()
+	}
+	wg.Wait()
+
+	if n := failed.Load(); n > 0 {
+		query.notices.add(data.NoticeSeverityWarning, "%d of %d hostnames failed to resolve within %s",
+			n, len(hosts), timeout)
+	}
+}
+
+// peerHosts determines the processes connected to a remote host, identified by address or name.
+func (query Query) peerHosts(tb process.Table, itr process.Tree, host string) map[Pid]struct{} {
+	pids := map[Pid]struct{}{}
+	for _, connected := range query.peerAddrs(tb, itr, host) {
+		for _, pid := range connected {
+			pids[pid] = struct{}{}
+		}
+	}
+	return pids
+}
+
+// peerAddrs determines the addresses of a remote host, identified by address or name, that the processes of the
+// tree connect to, mapped to the connected processes.
+func (query Query) peerAddrs(tb process.Table, itr process.Tree, host string) map[string][]Pid {
+	addrs := map[string][]Pid{}
+	for _, pid := range itr.All() {
+		if tb[pid] == nil {
+			continue
+		}
+		for _, conn := range tb[pid].Connections {
+			if conn.Peer.Pid < 0 && nodeArc(conn) == hostArc {
+				addr, _, _ := net.SplitHostPort(conn.Peer.Name)
+				addrs[addr] = append(addrs[addr], pid)
+			}
+		}
+	}
+
+	timeout := query.hostnameTimeout()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	matched := map[string][]Pid{}
+	for addr, connected := range addrs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if addr != host {
+				name, _ := hostname(addr, timeout)
+				if !strings.EqualFold(name, host) && !strings.EqualFold(query.settings.styled(name), host) {
+					return
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			matched[addr] = connected
+		}()
+	}
+	wg.Wait()
+
+	return matched
+}
@@ -0,0 +1,82 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"math"
+)
+
+// Node ids are laid out so that every id is below 2^53, the largest integer that the frontend's JavaScript numbers
+// represent exactly, and so that the kind of a node is determined by its id alone:
+//
+//	id < 0                   host node, gomon's id of the remote host or listener
+//	bits 0-31                pid of a process or thread node, or gomon's id (>= math.MaxInt32) of a data node
+//	bits 32-49 (qualifier)   low bits of a process' start time in seconds, set by stableIds
+//	bits 50-52 (kind)        0 for process and gomon data nodes, else selfKind, fileKind, or closedKind
+//
+// The pid of a process remains in the low 32 bits of its node's id and of the ids of the nodes derived from it,
+// from which pidOf recovers it for node graph links.
+const (
+	// kindShift is the position of the kind bits of an id.
+	kindShift = 50
+
+	// qualifierMask selects the bits of a process node's id that qualify its pid.
+	qualifierMask = 1<<kindShift - 1 - math.MaxUint32
+
+	// selfKind identifies the satellite node of a process' intra-process IPC, with the pid in the low bits.
+	selfKind = 1
+
+	// fileKind identifies a file node that unifyFiles distinguishes by device and inode, numbered in the low bits.
+	fileKind = 2
+
+	// closedKind identifies the node of an exited peer, with the pid of the exited process in the low bits.
+	closedKind = 3
+
+	// selfBase, fileBase, and closedBase are the first ids of their kinds.
+	selfBase   = selfKind << kindShift
+	fileBase   = fileKind << kindShift
+	closedBase = closedKind << kindShift
+)
+
+// kind reports the kind of a node id.
+func kind(id Pid) Pid {
+	if id < 0 {
+		return 0
+	}
+	return id >> kindShift
+}
+
+// isProcess reports whether a node id identifies a process rather than a host or data pseudo-node.
+func isProcess(pid Pid) bool {
+	return pid >= 0 && kind(pid) == 0 && pid&math.MaxUint32 < math.MaxInt32
+}
+
+// isData reports whether a node id identifies a data pseudo-node, i.e. a file, socket, pipe, or derived node.
+func isData(pid Pid) bool {
+	return pid >= 0 && !isProcess(pid)
+}
+
+// isClosed reports whether a node id identifies the node of an exited peer.
+func isClosed(pid Pid) bool {
+	return kind(pid) == closedKind
+}
+
+// pidOf recovers the pid from a node id qualified by stableIds. The id of a host, data, self, or closed node
+// identifies no process, so its pid is 0.
+func pidOf(id Pid) Pid {
+	if !isProcess(id) {
+		return 0
+	}
+	return id & math.MaxUint32
+}
+
+// pidsOf recovers the pids from a list of node ids qualified by stableIds, dropping the ids of no process.
+func pidsOf(ids []Pid) []Pid {
+	pids := ids[:0]
+	for _, id := range ids {
+		if pid := pidOf(id); pid != 0 {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
@@ -0,0 +1,60 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/zosmac/gomon/process"
+)
+
+// parseNetworks parses the settings' internal networks, reporting an invalid CIDR.
+func (settings *dataSourceSettings) parseNetworks() error {
+	settings.internal = nil
+	for i, cidr := range settings.InternalNetworks {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("internalNetworks[%d] %q is not a valid CIDR: %w", i, cidr, err)
+		}
+		settings.internal = append(settings.internal, network)
+	}
+	return nil
+}
+
+// local reports whether an address is never routed beyond the host's links: loopback, link-local unicast or
+// multicast, interface-local multicast, or IPv6 unique local (fc00::/7).
+func local(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.To4() == nil && ip.IsPrivate()
+}
+
+// internal reports whether a host connection's peer address is local to the host rather than remote, either by
+// its class or by being in one of the settings' internal networks, e.g. a container bridge.
+func (query Query) internal(conn process.Connection) bool {
+	if conn.Peer.Pid >= 0 || nodeArc(conn) != hostArc {
+		return false
+	}
+	host, _, err := net.SplitHostPort(conn.Peer.Name)
+	if err != nil {
+		return false
+	}
+	host, _, _ = strings.Cut(host, "%") // zone of a link-local address
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	if local(ip) {
+		return true
+	}
+	for _, network := range query.settings.internal {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"net"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	for _, tt := range []struct {
+		ip   string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"169.254.10.1", true},
+		{"fe80::1", true},
+		{"ff02::1", true},      // link-local multicast
+		{"ff01::1", true},      // interface-local multicast
+		{"fd12:3456::1", true}, // unique local
+		{"fc00::1", true},
+		{"10.0.0.1", false}, // private IPv4 may be routed beyond the host's links
+		{"192.168.1.1", false},
+		{"2001:db8::1", false},
+		{"ff05::1", false}, // site-local multicast
+		{"8.8.8.8", false},
+		{"::ffff:127.0.0.1", true},
+	} {
+		if got := local(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("local(%s) = %t, want %t", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestInternal(t *testing.T) {
+	settings := dataSourceSettings{InternalNetworks: []string{"172.17.0.0/16"}}
+	if err := settings.parseNetworks(); err != nil {
+		t.Fatal(err)
+	}
+	query := testQuery(queryModel{}, settings)
+	for _, tt := range []struct {
+		peer string
+		want bool
+	}{
+		{"172.17.0.2:5432", true},
+		{"172.18.0.2:5432", false},
+		{"[::1]:8080", true},
+		{"[fe80::1%eth0]:22", true}, // zone of a link-local address
+		{"203.0.113.7:443", false},
+		{"localhost", false}, // no port
+	} {
+		conn := testConnection("TCP", 20, "10.0.0.1:40000", -3, tt.peer)
+		if got := query.internal(conn); got != tt.want {
+			t.Errorf("internal(%s) = %t, want %t", tt.peer, got, tt.want)
+		}
+	}
+
+	settings.InternalNetworks = []string{"172.17.0.0"}
+	if err := settings.parseNetworks(); err == nil {
+		t.Error("parseNetworks accepted an address without a prefix length")
+	}
+}
+
+func TestRemoteOnlyKeepsInternalEdges(t *testing.T) {
+	tb := snapshotTable()
+	tb[20].Connections[0].Peer.Name = "172.17.0.2:5432" // the server's peer is on a container bridge
+	settings := dataSourceSettings{InternalNetworks: []string{"172.17.0.0/16"}}
+	if err := settings.parseNetworks(); err != nil {
+		t.Fatal(err)
+	}
+
+	g := testQuery(queryModel{}, settings).selected(tb)
+	if !hasEdge(g, -3, 20) {
+		t.Error("edge from the internal host to the server is dropped")
+	}
+
+	g = testQuery(queryModel{RemoteOnly: true}, settings).selected(tb)
+	if hasEdge(g, -3, 20) {
+		t.Error("remote only graph retains the server connected only to an internal host")
+	}
+	if !hasEdge(g, -4, 30) {
+		t.Error("remote only graph drops the client connected to a remote host")
+	}
+}
+
+// hasEdge reports whether a graph has an edge between two nodes, identified by host id or pid.
+func hasEdge(g graph, src, tgt Pid) bool {
+	for _, e := range g.edges {
+		if nodeId(Pid(e[1].(int64))) == src && nodeId(Pid(e[2].(int64))) == tgt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/zosmac/gomon/process"
+)
+
+// selfConnections adds the connections of the graph's processes to themselves, e.g. socketpairs and loopback,
+// that gomon omits. The connections of each process link it to a satellite node for its intra-process IPC.
+// The connections are filtered as the other edges' are.
+func (query Query) selfConnections(
+	tb process.Table,
+	itr process.Tree,
+	folded map[Pid]Pid,
+	dropped map[Pid]struct{},
+	datas map[Pid][]any,
+	edges map[[2]Pid][]any,
+) {
+	selfs := map[[2]Pid][]any{}
+	for _, pid := range itr.All() {
+		if _, ok := folded[pid]; ok {
+			continue
+		}
+		if _, ok := dropped[pid]; ok || tb[pid] == nil {
+			continue
+		}
+		for _, conn := range tb[pid].Connections {
+			if conn.Peer.Pid != pid {
+				continue
+			}
+			id := [2]Pid{pid, selfBase | pid}
+			if _, ok := selfs[id]; !ok {
+				selfs[id] = query.selfEdge(tb[pid])
+			}
+			selfs[id] = append(selfs[id], fmt.Sprintf(
+				"%s"+query.Arrow()+"%s:%s<->%s",
+				tb[pid].Shortname(),
+				conn.Type,
+				conn.Self.Name,
+				conn.Peer.Name,
+			))
+		}
+	}
+
+	query.connectionFilters(selfs)
+	for id, edge := range selfs {
+		datas[id[1]] = query.selfNode(tb[id[0]])
+		edges[id] = edge
+	}
+}
+
+// selfNode creates the satellite node of a process' intra-process IPC.
+func (query Query) selfNode(p *process.Process) []any {
+	node := append(append([]any{
+		int64(selfBase | p.Pid),
+		"intra-process IPC",
+		p.Shortname(),
+		"intra-process IPC of " + p.Longname(),
+	}, arc(kernArc)...), pseudoDetails()...)
+	node[hostDetail] = localHost()
+	node[sizeDetail] = defaultSize
+	return node
+}
+
+// selfEdge creates the edge from a process to the satellite node of its intra-process IPC.
+func (query Query) selfEdge(p *process.Process) []any {
+	edge := newEdge(p.Pid, selfBase|p.Pid, p.Shortname(), "intra-process IPC", "ipc")
+	edge[directionDetail] = "bidirectional"
+	return edge
+}
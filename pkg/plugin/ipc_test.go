@@ -0,0 +1,56 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/zosmac/gomon/process"
+)
+
+func TestSelfConnectionsFiltered(t *testing.T) {
+	tb := process.Table{
+		1: testProcess(1, 0, "init"),
+		20: testProcess(20, 1, "server",
+			testConnection("TCP", 20, "127.0.0.1:5000", 20, "127.0.0.1:5001"),
+			testConnection("unix", 20, "0x1", 20, "0x2"),
+		),
+	}
+
+	for _, tt := range []struct {
+		model queryModel
+		want  []string // the types of the retained self connections
+	}{
+		{queryModel{}, []string{"TCP", "unix"}},
+		{queryModel{HideLoopback: true}, []string{"unix"}},
+		{queryModel{Protocols: []string{"UNIX"}}, []string{"unix"}},
+		{queryModel{FileTypes: []string{"pipes"}}, []string{"TCP"}},
+		{queryModel{FileTypes: []string{"pipes"}, Protocols: []string{"unix"}}, nil},
+	} {
+		tt.model.ShowSelfConnections = true
+		query := testQuery(tt.model, dataSourceSettings{})
+		g := query.selected(tb)
+
+		var types []string
+		node := false
+		for _, e := range g.edges {
+			if kind(Pid(e[2].(int64))) != selfKind {
+				continue
+			}
+			for _, conn := range e[connIndex:] {
+				types = append(types, query.connectionType([2]Pid{20, selfBase | 20}, conn.(string)))
+			}
+		}
+		for _, n := range g.nodes {
+			node = node || kind(Pid(n[0].(int64))) == selfKind
+		}
+		slices.Sort(types)
+		if !slices.Equal(types, tt.want) {
+			t.Errorf("query %+v self connections %v, want %v", tt.model, types, tt.want)
+		}
+		if node != (len(tt.want) > 0) {
+			t.Errorf("query %+v self node %t with %d connections", tt.model, node, len(tt.want))
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/zosmac/gomon/process"
+)
+
+// listeners adds the listening sockets of the graph's processes as host nodes, including those that gomon omits
+// for processes with no connected peers. Descriptors of a process on the same listen address share an edge.
+func (query Query) listeners(
+	tb process.Table,
+	itr process.Tree,
+	folded map[Pid]Pid,
+	dropped map[Pid]struct{},
+	hosts map[Pid][]any,
+	edges map[[2]Pid][]any,
+) {
+	for _, pid := range itr.All() {
+		if _, ok := folded[pid]; ok {
+			continue
+		}
+		if _, ok := dropped[pid]; ok || tb[pid] == nil {
+			continue
+		}
+		for _, conn := range tb[pid].Connections {
+			if conn.Peer.Pid >= 0 || nodeArc(conn) != sockArc {
+				continue
+			}
+			if !query.protocol(conn.Type) {
+				continue
+			}
+			if _, ok := hosts[conn.Peer.Pid]; !ok {
+				hosts[conn.Peer.Pid] = query.HostNode(conn)
+			}
+			id := [2]Pid{conn.Peer.Pid, pid}
+			if _, ok := edges[id]; ok {
+				continue // gomon reported the listener, or another descriptor on the listen address
+			}
+			edges[id] = append(query.HostEdge(tb, conn), fmt.Sprintf(
+				"%s:%s"+query.Arrow()+"%s[%d]",
+				conn.Type,
+				conn.Peer.Name,
+				conn.Self.Name,
+				conn.Self.Pid,
+			))
+		}
+	}
+}
+
+// socketCounts counts a process' connected and listening sockets, and the remote hosts it connects to.
+// Sockets with a remote peer are counted as established.
+func socketCounts(p *process.Process) (established, listening, remotes int64) {
+	hosts := map[string]struct{}{}
+	for _, conn := range p.Connections {
+		if conn.Peer.Pid >= 0 {
+			continue
+		}
+		if isListenSocket(conn.Self.Name) {
+			listening++
+			continue
+		}
+		established++
+		host, _, _ := net.SplitHostPort(conn.Peer.Name)
+		hosts[host] = struct{}{}
+	}
+	return established, listening, int64(len(hosts))
+}
@@ -0,0 +1,53 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"net"
+	"strings"
+)
+
+// hideLoopback removes the host and inter-process connections whose endpoints are both loopback addresses,
+// keeping the unix socket, pipe, and other local IPC connections that have no addresses.
+func (query Query) hideLoopback(edges map[[2]Pid][]any) {
+	query.filterConnections(edges, func(id [2]Pid, conn string) bool {
+		if kind(id[1]) == selfKind {
+			return !query.selfLoopback(conn)
+		}
+		return isData(id[1]) || !query.loopback(conn)
+	})
+}
+
+// selfLoopback reports whether both endpoints of an intra-process connection's description are loopback addresses,
+// e.g. server -> TCP:127.0.0.1:8080<->127.0.0.1:51234.
+func (query Query) selfLoopback(conn string) bool {
+	_, conn, _ = strings.Cut(conn, query.Arrow())
+	_, conn, _ = strings.Cut(conn, ":") // the connection type
+	self, peer, ok := strings.Cut(conn, "<->")
+	return ok && loopbackAddr(self) && loopbackAddr(peer)
+}
+
+// loopback reports whether both endpoints of a host or inter-process connection's description are loopback
+// addresses, e.g. TCP:127.0.0.1:8080[10] -> 127.0.0.1:51234[20].
+func (query Query) loopback(conn string) bool {
+	if strings.HasPrefix(conn, "parent:") {
+		return false
+	}
+	_, conn, _ = strings.Cut(conn, ":") // the connection type
+	self, peer, ok := strings.Cut(conn, query.Arrow())
+	return ok && loopbackAddr(self) && loopbackAddr(peer)
+}
+
+// loopbackAddr reports whether an endpoint, a host:port optionally followed by its process' [pid], is a loopback
+// address.
+func loopbackAddr(endpoint string) bool {
+	if i := strings.LastIndexByte(endpoint, '['); i > 0 && strings.HasSuffix(endpoint, "]") {
+		endpoint = endpoint[:i]
+	}
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,67 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/zosmac/gomon/process"
+)
+
+func TestLoopback(t *testing.T) {
+	query := testQuery(queryModel{}, dataSourceSettings{})
+	for _, tt := range []struct {
+		conn string
+		want bool
+	}{
+		{"TCP:127.0.0.1:51000 -> 127.0.0.1:9100[20]", true},
+		{"TCP6:[::1]:51000 -> [::1]:9100[20]", true},
+		{"TCP:127.0.0.1:5432[20] -> 127.0.0.1:51001[30]", true},
+		{"TCP:127.0.0.1:6000[20] -> 10.0.0.2:51002[30]", false}, // bridges to a non-loopback peer
+		{"TCP:10.0.0.8:40000 -> 127.0.0.1:9100[20]", false},
+		{"TCP6:[::1]:51000 -> [fe80::1]:9100[20]", false},
+		{"unix:exporter[20] -> client[30]", false},
+		{"parent:init -> exporter", false},
+	} {
+		if got := query.loopback(tt.conn); got != tt.want {
+			t.Errorf("loopback(%s) = %t, want %t", tt.conn, got, tt.want)
+		}
+	}
+}
+
+func TestHideLoopback(t *testing.T) {
+	tb := process.Table{
+		1: testProcess(1, 0, "init"),
+		20: testProcess(20, 1, "exporter",
+			testConnection("TCP", 20, "127.0.0.1:9100", -3, "127.0.0.1:51000"),
+			testConnection("TCP", 20, "10.0.0.2:9100", -4, "10.0.0.8:40000"),
+			testConnection("TCP", 20, "127.0.0.1:5432", 30, "127.0.0.1:51001"),
+			testConnection("TCP", 20, "127.0.0.1:6000", 30, "10.0.0.2:51002"),
+			testConnection("unix", 20, "exporter", 30, "client"),
+		),
+		30: testProcess(30, 1, "client"),
+	}
+
+	g := testQuery(queryModel{HideLoopback: true}, dataSourceSettings{}).selected(tb)
+	if hasEdge(g, -3, 20) {
+		t.Error("edge of a loopback only host connection is retained")
+	}
+	if !hasEdge(g, -4, 20) {
+		t.Error("edge of a remote host connection is dropped")
+	}
+	for _, e := range g.edges {
+		if pidOf(Pid(e[1].(int64))) != 20 || pidOf(Pid(e[2].(int64))) != 30 {
+			continue
+		}
+		want := []any{
+			"TCP:127.0.0.1:6000[20] -> 10.0.0.2:51002[30]",
+			"unix:exporter[20] -> client[30]",
+		}
+		if conns := e[connIndex:]; !slices.Equal(conns, want) {
+			t.Errorf("connections of the inter-process edge are %v, want %v", conns, want)
+		}
+		return
+	}
+	t.Error("inter-process edge with a non-loopback connection is dropped")
+}
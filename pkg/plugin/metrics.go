@@ -0,0 +1,130 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// metric defines a collector internal reported in Prometheus text format.
+	metric interface {
+		write(io.Writer)
+	}
+
+	// gauge reports the current value of a metric.
+	gauge struct {
+		name  string
+		help  string
+		value func() float64
+	}
+
+	// histogram accumulates observations into cumulative buckets.
+	histogram struct {
+		sync.Mutex
+		name   string
+		help   string
+		bounds []float64
+		counts []uint64
+		sum    float64
+		count  uint64
+	}
+)
+
+var (
+	// processCount and connectionCount record the size of the most recently collected process table.
+	processCount    atomic.Int64
+	connectionCount atomic.Int64
+
+	// refreshed records when the process table was most recently collected, in Unix nanoseconds.
+	refreshed atomic.Int64
+
+	// nodegraphDuration accumulates the times to build the node graph.
+	nodegraphDuration = &histogram{
+		name:   "gomon_datasource_nodegraph_duration_seconds",
+		help:   "Time to build the process connections node graph.",
+		bounds: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		counts: make([]uint64, 10),
+	}
+
+	// registry lists the metrics reported by the metrics resource.
+	registry = []metric{
+		gauge{
+			name:  "gomon_datasource_processes",
+			help:  "Number of processes in the most recent process table.",
+			value: func() float64 { return float64(processCount.Load()) },
+		},
+		gauge{
+			name:  "gomon_datasource_connections",
+			help:  "Number of connections in the most recent process table.",
+			value: func() float64 { return float64(connectionCount.Load()) },
+		},
+		gauge{
+			name: "gomon_datasource_refresh_age_seconds",
+			help: "Time since the process table was most recently collected.",
+			value: func() float64 {
+				if t := refreshed.Load(); t > 0 {
+					return time.Since(time.Unix(0, t)).Seconds()
+				}
+				return -1
+			},
+		},
+		nodegraphDuration,
+		phaseDurations["collect"],
+		phaseDurations["assemble"],
+		phaseDurations["dns"],
+		phaseDurations["frames"],
+	}
+)
+
+// metrics formats the registered metrics in Prometheus text exposition format.
+func metrics() []byte {
+	buf := &bytes.Buffer{}
+	for _, m := range registry {
+		m.write(buf)
+	}
+	return buf.Bytes()
+}
+
+// write formats a gauge.
+func (g gauge) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n",
+		g.name, g.help, g.name, g.name, formatFloat(g.value()))
+}
+
+// observe adds an observation to the histogram.
+func (h *histogram) observe(d time.Duration) {
+	h.Lock()
+	defer h.Unlock()
+	v := d.Seconds()
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// write formats a histogram.
+func (h *histogram) write(w io.Writer) {
+	h.Lock()
+	defer h.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	for i, bound := range h.bounds {
+		fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", h.name, formatFloat(bound), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.name, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n%s_count %d\n", h.name, formatFloat(h.sum), h.name, h.count)
+}
+
+// formatFloat formats a metric value.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,22 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRefreshAge(t *testing.T) {
+	defer refreshed.Store(refreshed.Load())
+
+	tb, _ := selectTable()
+	query := testQuery(queryModel{}, dataSourceSettings{})
+	query.live = true
+	query.collected = time.Now().Add(-time.Minute) // the graph is built well after the table was collected
+	query.selected(tb)
+
+	if got := time.Unix(0, refreshed.Load()); !got.Equal(query.collected) {
+		t.Errorf("refreshed at %v, want the collection time %v", got, query.collected)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// accessModes reads the access modes of the files the process has open, keyed by path.
+// A file opened by several descriptors reports the union of their modes.
+func accessModes(pid Pid) map[string]string {
+	dir := filepath.Join("/proc", pid.String())
+	fds, err := os.ReadDir(filepath.Join(dir, "fd"))
+	if err != nil {
+		return nil
+	}
+
+	access := map[string]int{}
+	for _, fd := range fds {
+		path, err := os.Readlink(filepath.Join(dir, "fd", fd.Name()))
+		if err != nil || !filepath.IsAbs(path) {
+			continue
+		}
+		if flags, ok := fdFlags(filepath.Join(dir, "fdinfo", fd.Name())); ok {
+			switch flags & syscall.O_ACCMODE {
+			case syscall.O_RDONLY:
+				access[path] |= 1
+			case syscall.O_WRONLY:
+				access[path] |= 2
+			case syscall.O_RDWR:
+				access[path] |= 3
+			}
+		}
+	}
+
+	modes := map[string]string{}
+	for path, a := range access {
+		modes[path] = [...]string{"", "r", "w", "rw"}[a]
+	}
+	return modes
+}
+
+// fdFlags reads the open flags of a descriptor from its fdinfo.
+func fdFlags(fdinfo string) (int, bool) {
+	f, err := os.Open(fdinfo)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if value, ok := strings.CutPrefix(sc.Text(), "flags:"); ok {
+			flags, err := strconv.ParseInt(strings.TrimSpace(value), 8, 64)
+			return int(flags), err == nil
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,10 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+//go:build !linux
+
+package plugin
+
+// accessModes are only determined for Linux processes.
+func accessModes(Pid) map[string]string {
+	return nil
+}
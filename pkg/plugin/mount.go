@@ -0,0 +1,72 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/zosmac/gomon/process"
+)
+
+// unifyFiles keys the regular file and directory nodes by their device and inode rather than by their path, as
+// each process resolves the path in its own mount namespace. The paths of hard links and bind mounts of a file
+// join one node, while a path that names different files in different namespaces splits into a node per file.
+// The nodes' names report their canonical paths.
+func (query Query) unifyFiles(tb process.Table, datas map[Pid][]any, edges map[[2]Pid][]any) {
+	if !query.live { // a replayed table's files are not on this system
+		return
+	}
+
+	ids := map[string]Pid{}       // node ids by device:inode
+	claimed := map[Pid]struct{}{} // node ids keyed to a device:inode
+	next := Pid(fileBase)
+	for _, id := range slices.SortedFunc(maps.Keys(edges), func(a, b [2]Pid) int {
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	}) {
+		node, ok := datas[id[1]]
+		if !ok || !isProcess(id[0]) || tb[id[0]] == nil {
+			continue
+		}
+		if typ := node[1].(string); typ != "REG" && typ != "DIR" {
+			continue
+		}
+		key, canonical := fileIdentity(id[0], node[2].(string))
+		if key == "" {
+			continue
+		}
+
+		nid, ok := ids[key]
+		if !ok {
+			if _, ok := claimed[id[1]]; !ok {
+				nid = id[1]
+			} else {
+				nid = next
+				next++
+			}
+			ids[key] = nid
+			claimed[nid] = struct{}{}
+			if nid != id[1] {
+				datas[nid] = slices.Clone(node)
+				datas[nid][0] = int64(nid)
+			}
+			datas[nid][3] = node[1].(string) + ":" + canonical
+		}
+		if nid == id[1] {
+			continue
+		}
+
+		edge := edges[id]
+		delete(edges, id)
+		nidEdge := [2]Pid{id[0], nid}
+		if e, ok := edges[nidEdge]; ok {
+			edges[nidEdge] = append(e, edge[connIndex:]...)
+			continue
+		}
+		edge[0] = fmt.Sprintf("%d -> %d", id[0], nid)
+		edge[2] = int64(nid)
+		edges[nidEdge] = edge
+	}
+}
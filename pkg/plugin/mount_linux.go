@@ -0,0 +1,33 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// fileIdentity stats a file that a process opened through the process' root, so that the file is resolved in the
+// process' mount namespace. It reports the file's device:inode, and its canonical path in that namespace.
+func fileIdentity(pid Pid, path string) (key, canonical string) {
+	root := filepath.Join("/proc", pid.String(), "root")
+	var st syscall.Stat_t
+	if err := syscall.Stat(root+path, &st); err != nil {
+		return "", path
+	}
+	key = strconv.FormatUint(uint64(st.Dev), 10) + ":" + strconv.FormatUint(st.Ino, 10)
+
+	canonical = path
+	if resolved, err := filepath.EvalSymlinks(root + path); err == nil {
+		// the symlinks of the process' root resolve to the host's view of the root
+		if target, err := os.Readlink(root); err == nil {
+			if rel, ok := strings.CutPrefix(resolved, filepath.Clean(target)); ok {
+				canonical = "/" + strings.TrimPrefix(rel, "/")
+			}
+		}
+	}
+	return key, canonical
+}
@@ -0,0 +1,10 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+//go:build !linux
+
+package plugin
+
+// fileIdentity is only determined for Linux files, which are keyed by their path.
+func fileIdentity(_ Pid, path string) (key, canonical string) {
+	return "", path
+}
@@ -0,0 +1,44 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// pidNamespace reads the inode of the process' pid namespace, and the process' pid in that namespace from the NSpid
+// of its status. Graphs identify processes by their host pid; a process in the host namespace reports its host pid.
+func pidNamespace(pid Pid) (ns string, nspid Pid) {
+	link, err := os.Readlink(filepath.Join("/proc", pid.String(), "ns", "pid"))
+	if err == nil { // e.g. pid:[4026531836]
+		ns = strings.TrimSuffix(strings.TrimPrefix(link, "pid:["), "]")
+	}
+
+	nspid = pid
+	f, err := os.Open(filepath.Join("/proc", pid.String(), "status"))
+	if err != nil {
+		return ns, nspid
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		value, ok := strings.CutPrefix(sc.Text(), "NSpid:")
+		if !ok {
+			continue
+		}
+		// the pids of the nested namespaces, outermost first
+		if fields := strings.Fields(value); len(fields) > 0 {
+			if n, err := strconv.Atoi(fields[len(fields)-1]); err == nil {
+				nspid = Pid(n)
+			}
+		}
+		break
+	}
+
+	return ns, nspid
+}
@@ -0,0 +1,10 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+//go:build !linux
+
+package plugin
+
+// pidNamespace is only determined for Linux processes, which report their own pid.
+func pidNamespace(pid Pid) (ns string, nspid Pid) {
+	return "", pid
+}
@@ -4,55 +4,236 @@ package plugin
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
-func nodeFrames(link string, ns, es [][]any, maxConnections int) []*data.Frame {
-	timestamp := time.Now()
+type (
+	// field describes a field of a frame.
+	field struct {
+		path      string
+		display   string
+		fieldType data.FieldType
+		color     string
+	}
+)
+
+var (
+	// arcFields describes the arcs of the nodes frame, indexed by arc.
+	arcFields = [arcs]field{
+		hostArc:      {path: "host", display: "Host", color: "red"},
+		procArc:      {path: "process", display: "Process", color: "blue"},
+		dataArc:      {path: "data", display: "Data", color: "yellow"},
+		sockArc:      {path: "socket", display: "Socket", color: "magenta"},
+		kernArc:      {path: "kernel", display: "Kernel", color: "cyan"},
+		threadArc:    {path: "thread", display: "Thread", color: "orange"},
+		shmArc:       {path: "shm", display: "Shared Memory", color: "green"},
+		gpuArc:       {path: "gpu", display: "GPU", color: "dark-blue"},
+		warnArc:      {path: "warning", display: "Warning", color: "purple"},
+		transientArc: {path: "transient", display: "Transient", color: "text"},
+		closedArc:    {path: "closed", display: "Closed", color: "dark-red"},
+	}
+
+	// edgeDetails describes the detail fields that precede the connections in the edges frame.
+	edgeDetails = []field{
+		{path: "direction", display: "Direction", fieldType: data.FieldTypeString},
+		{path: "protocol", display: "Protocol", fieldType: data.FieldTypeString},
+		{path: "peerPort", display: "Peer Port", fieldType: data.FieldTypeString},
+		{path: "sent", display: "Sent (B/s)", fieldType: data.FieldTypeNullableFloat64},
+		{path: "received", display: "Received (B/s)", fieldType: data.FieldTypeNullableFloat64},
+		{path: "rtt", display: "RTT (ms)", fieldType: data.FieldTypeNullableFloat64},
+		{path: "flowBytes", display: "Flow Bytes", fieldType: data.FieldTypeNullableInt64},
+		{path: "flowPackets", display: "Flow Packets", fieldType: data.FieldTypeNullableInt64},
+		{path: "mode", display: "Access Mode", fieldType: data.FieldTypeString},
+		{path: "encrypted", display: "Encrypted", fieldType: data.FieldTypeNullableBool},
+		{path: "seen", display: "Seen In Snapshots", fieldType: data.FieldTypeNullableInt64},
+		{path: "lastSeen", display: "Last Seen", fieldType: data.FieldTypeNullableTime},
+		{path: "sendQueue", display: "Send Queue (B)", fieldType: data.FieldTypeNullableInt64},
+		{path: "recvQueue", display: "Receive Queue (B)", fieldType: data.FieldTypeNullableInt64},
+	}
+
+	// connIndex is the index in an edge of its first connection.
+	connIndex = 5 + len(edgeDetails)
+
+	// nodeDetails describes the detail fields that follow the arcs in the nodes frame.
+	nodeDetails = []field{
+		{path: "container", display: "Container", fieldType: data.FieldTypeString},
+		{path: "user", display: "User", fieldType: data.FieldTypeString},
+		{path: "cmdline", display: "Command Line", fieldType: data.FieldTypeString},
+		{path: "priority", display: "Priority", fieldType: data.FieldTypeInt64},
+		{path: "nice", display: "Nice", fieldType: data.FieldTypeInt64},
+		{path: "policy", display: "Scheduling Policy", fieldType: data.FieldTypeString},
+		{path: "reexec", display: "Re-exec'd From", fieldType: data.FieldTypeString},
+		{path: "host", display: "Host", fieldType: data.FieldTypeString},
+		{path: "service", display: "Service", fieldType: data.FieldTypeString},
+		{path: "size", display: "Size", fieldType: data.FieldTypeFloat64},
+		{path: "capabilities", display: "Capabilities", fieldType: data.FieldTypeString},
+		{path: "seccomp", display: "Seccomp", fieldType: data.FieldTypeString},
+		{path: "device", display: "Device", fieldType: data.FieldTypeString},
+		{path: "established", display: "Established", fieldType: data.FieldTypeInt64},
+		{path: "listening", display: "Listening", fieldType: data.FieldTypeInt64},
+		{path: "remoteHosts", display: "Remote Hosts", fieldType: data.FieldTypeInt64},
+		{path: "warnings", display: "Warnings", fieldType: data.FieldTypeString},
+		{path: "group", display: "Group", fieldType: data.FieldTypeString},
+		{path: "pidNamespace", display: "PID Namespace", fieldType: data.FieldTypeString},
+		{path: "namespacedPid", display: "Namespaced PID", fieldType: data.FieldTypeInt64},
+		{path: "transient", display: "Transient", fieldType: data.FieldTypeString},
+	}
+
+	// hostDetail is the index in a node of the host detail that groups the nodes of each host.
+	hostDetail = detailIndex("host")
+
+	// serviceDetail is the index in a host node of the service of its port.
+	serviceDetail = detailIndex("service")
+
+	// sizeDetail is the index in a node of its size, for mapping to the node's radius.
+	sizeDetail = detailIndex("size")
+
+	// deviceDetail is the index in a data node of the major:minor number of a block or character device.
+	deviceDetail = detailIndex("device")
+
+	// warningsDetail is the index in a process node of the reasons the warning heuristics flag it.
+	warningsDetail = detailIndex("warnings")
+
+	// namespaceDetail is the index in a process node of the inode of its pid namespace.
+	namespaceDetail = detailIndex("pidNamespace")
+
+	// transientDetail is the index in a process node of the lifetime of a short-lived process.
+	transientDetail = detailIndex("transient")
+
+	// directionDetail is the index in an edge of the direction in which its connections were initiated.
+	directionDetail = edgeDetailIndex("direction")
 
-	nodes := data.NewFrameOfFieldTypes("nodes", len(ns),
-		data.FieldTypeTime,
+	// sentDetail and receivedDetail are the indices in an edge of its throughput in each direction.
+	sentDetail     = edgeDetailIndex("sent")
+	receivedDetail = edgeDetailIndex("received")
+
+	// rttDetail is the index in an edge of the round trip time of its connections.
+	rttDetail = edgeDetailIndex("rtt")
+
+	// flowBytesDetail and flowPacketsDetail are the indices in an edge of its conntrack flow counts.
+	flowBytesDetail   = edgeDetailIndex("flowBytes")
+	flowPacketsDetail = edgeDetailIndex("flowPackets")
+
+	// protocolDetail, peerPortDetail, modeDetail, and encryptedDetail are the indices in an edge of its connections'
+	// protocol, the port of their peer, the access mode of a file, and whether the port is a TLS port.
+	protocolDetail  = edgeDetailIndex("protocol")
+	peerPortDetail  = edgeDetailIndex("peerPort")
+	modeDetail      = edgeDetailIndex("mode")
+	encryptedDetail = edgeDetailIndex("encrypted")
+
+	// sendQueueDetail and recvQueueDetail are the indices in an edge of the depths of its TCP queues.
+	sendQueueDetail = edgeDetailIndex("sendQueue")
+	recvQueueDetail = edgeDetailIndex("recvQueue")
+)
+
+// detailIndex determines the index in a node of a detail field.
+func detailIndex(path string) int {
+	return 4 + arcs + slices.IndexFunc(nodeDetails, func(detail field) bool {
+		return detail.path == path
+	})
+}
+
+// edgeDetailIndex determines the index in an edge of a detail field.
+func edgeDetailIndex(path string) int {
+	return 5 + slices.IndexFunc(edgeDetails, func(detail field) bool {
+		return detail.path == path
+	})
+}
+
+// nodeStats reports the counts of the graph's nodes and of its collected table, and the age of the collection.
+func nodeStats(g graph) []data.QueryStat {
+	stats := []data.QueryStat{{
+		FieldConfig: data.FieldConfig{
+			DisplayName: "Node Count",
+		},
+		Value: float64(len(g.nodes)),
+	}}
+	if g.collected.IsZero() {
+		return stats
+	}
+	return append(stats,
+		data.QueryStat{
+			FieldConfig: data.FieldConfig{
+				DisplayName: "Process Count",
+				Unit:        "short",
+			},
+			Value: float64(g.processes),
+		},
+		data.QueryStat{
+			FieldConfig: data.FieldConfig{
+				DisplayName: "Connection Count",
+				Unit:        "short",
+			},
+			Value: float64(g.connections),
+		},
+		data.QueryStat{
+			FieldConfig: data.FieldConfig{
+				DisplayName: "Collection Age (s)",
+				Unit:        "s",
+			},
+			Value: time.Since(g.collected).Seconds(),
+		},
+	)
+}
+
+// nodeFrames formats the nodes and edges of a node graph into data frames. The rows of a streamed graph carry
+// the graph's time; otherwise the frames are compact, recording the time once in their metadata.
+func nodeFrames(link string, g graph, streaming bool) []*data.Frame {
+	timestamp, ns, es, maxConnections := g.timestamp, g.nodes, g.edges, g.maxConnections
+
+	var flds []data.FieldType
+	var names []string
+	o := 0 // offset of the fields following the time field
+	if streaming {
+		flds, names, o = []data.FieldType{data.FieldTypeTime}, []string{"time"}, 1
+	}
+	flds = append(flds,
 		data.FieldTypeInt64,
 		data.FieldTypeString,
 		data.FieldTypeString,
 		data.FieldTypeString,
-		data.FieldTypeFloat64,
-		data.FieldTypeFloat64,
-		data.FieldTypeFloat64,
-		data.FieldTypeFloat64,
-		data.FieldTypeFloat64,
 	)
-	nodes.SetFieldNames(
-		"time",
+	names = append(names,
 		"id",
 		"mainStat",
 		"secondaryStat",
 		"detail__name",
-		"arc__host",
-		"arc__process",
-		"arc__data",
-		"arc__socket",
-		"arc__kernel",
 	)
+	for _, a := range arcFields {
+		flds = append(flds, data.FieldTypeFloat64)
+		names = append(names, "arc__"+a.path)
+	}
+	for _, detail := range nodeDetails {
+		flds = append(flds, detail.fieldType)
+		names = append(names, "detail__"+detail.path)
+	}
+	if g.colorByName {
+		flds = append(flds, data.FieldTypeString)
+		names = append(names, "color")
+	}
+
+	nodes := data.NewFrameOfFieldTypes("nodes", len(ns), flds...)
+	nodes.SetFieldNames(names...)
+
 	nodes.SetMeta(&data.FrameMeta{
 		Path:                   "node",
 		PreferredVisualization: data.VisType("nodeGraph"),
-		Stats: []data.QueryStat{{
-			FieldConfig: data.FieldConfig{
-				DisplayName: "Node Count",
-			},
-			Value: float64(len(ns)),
-		}},
+		Stats:                  nodeStats(g),
+		Notices:                g.notices,
+		Custom:                 map[string]any{"timestamp": timestamp},
 	})
 
-	nodes.Fields[0].Config = &data.FieldConfig{
-		DisplayName: "Time",
-		Path:        "time",
+	if streaming {
+		nodes.Fields[0].Config = &data.FieldConfig{
+			DisplayName: "Time",
+			Path:        "time",
+		}
 	}
-	nodes.Fields[1].Config = &data.FieldConfig{
+	nodes.Fields[o].Config = &data.FieldConfig{
 		DisplayName: "ID",
 		Path:        "id",
 		Links: []data.DataLink{{
@@ -60,63 +241,66 @@ func nodeFrames(link string, ns, es [][]any, maxConnections int) []*data.Frame {
 			URL:   link,
 		}},
 	}
-	nodes.Fields[2].Config = &data.FieldConfig{
+	nodes.Fields[o+1].Config = &data.FieldConfig{
 		DisplayName: "Service",
 		Path:        "service",
 	}
-	nodes.Fields[3].Config = &data.FieldConfig{
+	nodes.Fields[o+2].Config = &data.FieldConfig{
 		DisplayName: "Instance",
 		Path:        "instance",
 	}
-	nodes.Fields[4].Config = &data.FieldConfig{
+	nodes.Fields[o+3].Config = &data.FieldConfig{
 		DisplayName: "Name",
 		Path:        "name",
 	}
-	nodes.Fields[5].Config = &data.FieldConfig{
-		Color:       red,
-		DisplayName: "Host",
-		Path:        "host",
-	}
-	nodes.Fields[6].Config = &data.FieldConfig{
-		Color:       blue,
-		DisplayName: "Process",
-		Path:        "process",
-	}
-	nodes.Fields[7].Config = &data.FieldConfig{
-		Color:       yellow,
-		DisplayName: "Data",
-		Path:        "data",
+	for i, a := range arcFields {
+		nodes.Fields[i+o+4].Config = &data.FieldConfig{
+			Color:       map[string]any{"mode": "fixed", "fixedColor": a.color},
+			DisplayName: a.display,
+			Path:        a.path,
+		}
 	}
-	nodes.Fields[8].Config = &data.FieldConfig{
-		Color:       magenta,
-		DisplayName: "Socket",
-		Path:        "socket",
+	for i, detail := range nodeDetails {
+		nodes.Fields[i+o+4+arcs].Config = &data.FieldConfig{
+			DisplayName: detail.display,
+			Path:        detail.path,
+		}
 	}
-	nodes.Fields[9].Config = &data.FieldConfig{
-		Color:       cyan,
-		DisplayName: "Kernel",
-		Path:        "kernel",
+	if g.colorByName {
+		nodes.Fields[o+4+arcs+len(nodeDetails)].Config = &data.FieldConfig{
+			DisplayName: "Color",
+			Path:        "color",
+		}
 	}
 
 	for i, n := range ns {
-		nodes.SetRow(i, append([]any{timestamp}, n...)...)
+		if streaming {
+			n = append([]any{timestamp}, n...)
+		}
+		nodes.SetRow(i, n...)
 	}
 
-	flds := []data.FieldType{
-		data.FieldTypeTime,
+	flds, names = nil, nil
+	if streaming {
+		flds, names = []data.FieldType{data.FieldTypeTime}, []string{"time"}
+	}
+	flds = append(flds,
 		data.FieldTypeString,
 		data.FieldTypeInt64,
 		data.FieldTypeInt64,
 		data.FieldTypeString,
 		data.FieldTypeString,
-	}
-	names := []string{
-		"time",
+	)
+	names = append(names,
 		"id",
 		"source",
 		"target",
 		"mainStat",
 		"secondaryStat",
+	)
+	for _, detail := range edgeDetails {
+		flds = append(flds, detail.fieldType)
+		names = append(names, "detail__"+detail.path)
 	}
 	for i := range maxConnections {
 		flds = append(flds, data.FieldTypeString)
@@ -135,17 +319,20 @@ func nodeFrames(link string, ns, es [][]any, maxConnections int) []*data.Frame {
 			},
 			Value: float64(len(es)),
 		}},
+		Custom: map[string]any{"timestamp": timestamp},
 	})
 
-	edges.Fields[0].Config = &data.FieldConfig{
-		DisplayName: "Time",
-		Path:        "time",
+	if streaming {
+		edges.Fields[0].Config = &data.FieldConfig{
+			DisplayName: "Time",
+			Path:        "time",
+		}
 	}
-	edges.Fields[1].Config = &data.FieldConfig{
+	edges.Fields[o].Config = &data.FieldConfig{
 		DisplayName: "ID",
 		Path:        "id",
 	}
-	edges.Fields[2].Config = &data.FieldConfig{
+	edges.Fields[o+1].Config = &data.FieldConfig{
 		DisplayName: "Source_ID",
 		Path:        "source",
 		Links: []data.DataLink{{
@@ -153,7 +340,7 @@ func nodeFrames(link string, ns, es [][]any, maxConnections int) []*data.Frame {
 			URL:   link,
 		}},
 	}
-	edges.Fields[3].Config = &data.FieldConfig{
+	edges.Fields[o+2].Config = &data.FieldConfig{
 		DisplayName: "Target_ID",
 		Path:        "target",
 		Links: []data.DataLink{{
@@ -161,24 +348,34 @@ func nodeFrames(link string, ns, es [][]any, maxConnections int) []*data.Frame {
 			URL:   link,
 		}},
 	}
-	edges.Fields[4].Config = &data.FieldConfig{
+	edges.Fields[o+3].Config = &data.FieldConfig{
 		DisplayName: "Source",
 		Path:        "self",
 	}
-	edges.Fields[5].Config = &data.FieldConfig{
+	edges.Fields[o+4].Config = &data.FieldConfig{
 		DisplayName: "Target",
 		Path:        "peer",
 	}
 
+	for i, detail := range edgeDetails {
+		edges.Fields[i+o+5].Config = &data.FieldConfig{
+			DisplayName: detail.display,
+			Path:        detail.path,
+		}
+	}
+
 	for i := range maxConnections {
-		edges.Fields[i+6].Config = &data.FieldConfig{
+		edges.Fields[i+o+connIndex].Config = &data.FieldConfig{
 			DisplayName: fmt.Sprintf("Connection %d", i+1),
 			Path:        fmt.Sprintf("connection %d", i+1),
 		}
 	}
 
 	for i, e := range es {
-		edges.SetRow(i, append([]any{timestamp}, e...)...)
+		if streaming {
+			e = append([]any{timestamp}, e...)
+		}
+		edges.SetRow(i, e...)
 	}
 
 	return []*data.Frame{nodes, edges}
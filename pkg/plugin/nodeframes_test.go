@@ -0,0 +1,87 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestCollectionAge(t *testing.T) {
+	tb, _ := selectTable()
+	query := testQuery(queryModel{}, dataSourceSettings{})
+	query.collected = time.Now().Add(-time.Minute) // the graph is built well after the table was collected
+	stats := nodeStats(query.selected(tb))
+	if age := stats[len(stats)-1]; age.DisplayName != "Collection Age (s)" || age.Value < 60 {
+		t.Errorf("%s is %v, want at least 60", age.DisplayName, age.Value)
+	}
+
+	s := newSnapshots(1, minSnapshotInterval)
+	s.add(tb)
+	snap, _ := s.closest(time.Now())
+	if g := snap.build(query.ctx, queryModel{}, dataSourceSettings{}); !g.collected.Equal(snap.timestamp) {
+		t.Errorf("snapshot graph collected at %v, want the snapshot's time %v", g.collected, snap.timestamp)
+	}
+}
+
+func TestEdgeDetailIndex(t *testing.T) {
+	tb := snapshotTable()
+	query := testQuery(queryModel{}, dataSourceSettings{})
+	for _, edge := range [][]any{
+		query.ProcEdge(tb, 1, 20),
+		query.HostEdge(tb, tb[20].Connections[0]),
+		query.DataEdge(tb, testConnection("REG", 20, "", fileBase+1, "/var/log/app.log")),
+		query.threadEdge(tb[20], 25, "worker")[:connIndex],
+		query.selfEdge(tb[20]),
+	} {
+		if len(edge) != connIndex {
+			t.Fatalf("edge %v has %d fields, want %d", edge[0], len(edge), connIndex)
+		}
+		for _, detail := range edgeDetails {
+			if typ := data.FieldTypeFor(edge[edgeDetailIndex(detail.path)]); typ != detail.fieldType {
+				t.Errorf("edge %v detail %s is a %s, want %s", edge[0], detail.path, typ, detail.fieldType)
+			}
+		}
+	}
+	if edge := query.threadEdge(tb[20], 25, "worker"); edge[protocolDetail] != "thread" || len(edge) != connIndex+1 {
+		t.Errorf("thread edge protocol %v with %d connections", edge[protocolDetail], len(edge)-connIndex)
+	}
+	if directionDetail != 5 || sentDetail != 8 || receivedDetail != 9 || rttDetail != 10 {
+		t.Errorf("direction, sent, received, and rtt details at %d, %d, %d, %d",
+			directionDetail, sentDetail, receivedDetail, rttDetail)
+	}
+}
+
+func TestFramesDeterministic(t *testing.T) {
+	tb := snapshotTable()
+	for pid := Pid(40); pid < 60; pid++ { // siblings and connections whose map order varies between builds
+		tb[pid] = testProcess(pid, 20, "worker",
+			testConnection("TCP", pid, "10.0.0.2:443", -pid, "10.0.1."+pid.String()+":50000"),
+			testConnection("TCP", pid, "127.0.0.1:"+pid.String(), 30, "127.0.0.1:6000"),
+		)
+	}
+
+	timestamp := time.Now()
+	encode := func() []byte {
+		g := testQuery(queryModel{}, dataSourceSettings{}).selected(tb)
+		g.timestamp = timestamp // the time of the build is an intended difference
+		var buf []byte
+		for _, frame := range nodeFrames("", g, false) {
+			frame.Meta.Stats = nil // as is the collection age
+			b, err := frame.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf = append(buf, b...)
+		}
+		return buf
+	}
+	want := encode()
+	for range 5 {
+		if got := encode(); string(got) != string(want) {
+			t.Fatal("frames built from the same table differ")
+		}
+	}
+}
@@ -4,13 +4,18 @@ package plugin
 
 import (
 	"cmp"
+	"context"
 	"fmt"
 	"math"
 	"net"
+	"os"
 	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
@@ -23,58 +28,216 @@ type (
 	// Pid alias for Pid in process package.
 	Pid = process.Pid
 
+	// queryModel defines the query parameters sent by the query editor.
+	queryModel struct {
+		Pid                 Pid      `json:"pid"`
+		Streaming           bool     `json:"streaming"`
+		GroupByContainer    bool     `json:"groupByContainer"`
+		Protocols           []string `json:"protocols"`           // connection types to include, all if empty
+		Threads             bool     `json:"threads"`             // include Linux threads as children of their process
+		Collapse            []Pid    `json:"collapse"`            // processes whose descendants fold into them
+		MaxNodes            int      `json:"maxNodes"`            // limit of nodes in graph, 0 for no limit
+		MaxEdges            int      `json:"maxEdges"`            // limit of edges in graph, 0 for no limit
+		RemoteOnly          bool     `json:"remoteOnly"`          // only processes with remote host connections, and their ancestors
+		HideParentEdges     bool     `json:"hideParentEdges"`     // omit parent/child edges, keeping only resource connections
+		Unit                string   `json:"unit"`                // glob of the systemd units whose processes and peers to include
+		Depth               *int     `json:"depth"`               // levels of descendants of the selected pid, or of the roots, to include, all if unset
+		PinPids             []Pid    `json:"pinPids"`             // processes always included, with their direct edges
+		ExcludePids         []Pid    `json:"excludePids"`         // processes always removed, with their edges
+		SizeBy              string   `json:"sizeBy"`              // metric sizing process nodes: connections, cpu, memory, or fds
+		ShowListeners       bool     `json:"showListeners"`       // include the listening sockets of all processes in the graph
+		FileFilter          string   `json:"fileFilter"`          // glob or path prefix of the files to include as data nodes
+		PeerHost            string   `json:"peerHost"`            // address or name of a remote host whose connected processes to include
+		SharedMem           bool     `json:"sharedMem"`           // link the processes sharing POSIX shared memory and semaphores
+		TreeOnly            bool     `json:"treeOnly"`            // only the parent/child process tree, without connections
+		SeedFile            string   `json:"seedFile"`            // path or glob of a file whose connected processes center the graph
+		SeedHost            string   `json:"seedHost"`            // address or name of a remote host whose connected processes center the graph
+		ShowSelfConnections bool     `json:"showSelfConnections"` // link processes' connections to themselves to a satellite IPC node
+		HideGroupEdges      bool     `json:"hideGroupEdges"`      // omit the edges between the processes of a process group
+		FileTypes           []string `json:"fileTypes"`           // categories of data nodes to include: sockets, pipes, regular, devices, shared-memory
+		ColorByName         bool     `json:"colorByName"`         // color process nodes by a hash of their name
+		CrossBoundaryOnly   bool     `json:"crossBoundaryOnly"`   // only edges between processes of different users, containers, or pid namespaces
+		HideLoopback        bool     `json:"hideLoopback"`        // omit the connections whose endpoints are both loopback addresses
+		ShowTransients      bool     `json:"showTransients"`      // include the short-lived processes that recently exited
+		Window              bool     `json:"window"`              // union the snapshots retained within the query's time range
+	}
+
+	// graph holds the nodes and edges of a node graph built at a point in time.
+	graph struct {
+		timestamp      time.Time
+		nodes          [][]any
+		edges          [][]any
+		maxConnections int
+		notices        []data.Notice
+		timings        *timings
+		colorByName    bool      // nodes end with the value of the color field
+		processes      int       // processes of the collected table
+		connections    int       // connections of the collected table
+		collected      time.Time // when the table was collected
+	}
+
 	// query parameters for request.
 	Query struct {
-		pid  Pid
-		link string
+		ctx        context.Context
+		model      queryModel
+		settings   dataSourceSettings
+		containers map[Pid]string
+		rates      rates
+		rtts       rtts
+		queues     queues
+		flows      flows
+		notices    *notices
+		modes      map[Pid]map[string]string // access modes of the processes' open files
+		timings    *timings                  // durations of the phases of the build, if profiling
+		live       bool                      // the table is the live system's, so its processes' state may be read
+		prevCPU    map[Pid]time.Duration     // cpu times of the processes of the prior table
+		collected  time.Time                 // when the table and its connections were collected
 	}
 )
 
+const (
+	// arcs of the circle drawn around a node. Each arc has a specific color set in its field metadata to create a circle that identifies the node type.
+	hostArc = iota
+	procArc
+	dataArc
+	sockArc
+	kernArc
+	threadArc
+	shmArc
+	gpuArc
+	warnArc
+	transientArc
+	closedArc
+	arcs // count of arcs
+)
+
 var (
-	// host/proc specify the arc for the circle drawn around a node.
-	// Each arc has a specific color set in its field metadata to create a circle that identifies the node type.
-	hostColor = []any{1.0, 0.0, 0.0, 0.0, 0.0} // red
-	procColor = []any{0.0, 1.0, 0.0, 0.0, 0.0} // blue
-	dataColor = []any{0.0, 0.0, 1.0, 0.0, 0.0} // yellow
-	sockColor = []any{0.0, 0.0, 0.0, 1.0, 0.0} // magenta
-	kernColor = []any{0.0, 0.0, 0.0, 0.0, 1.0} // cyan
-	red       = map[string]any{"mode": "fixed", "fixedColor": "red"}
-	blue      = map[string]any{"mode": "fixed", "fixedColor": "blue"}
-	yellow    = map[string]any{"mode": "fixed", "fixedColor": "yellow"}
-	magenta   = map[string]any{"mode": "fixed", "fixedColor": "magenta"}
-	cyan      = map[string]any{"mode": "fixed", "fixedColor": "cyan"}
+	// graphLock serializes builds of the node graph, as the process package retains state between builds.
+	graphLock sync.Mutex
 )
 
 // color defines the color for grafana nodes.
 func color(conn process.Connection) []any {
-	var color []any
+	return arc(nodeArc(conn))
+}
+
+// nodeArc identifies the arc for a connection's peer node.
+func nodeArc(conn process.Connection) int {
+	var a int
 	if conn.Peer.Pid < 0 {
-		color = hostColor
-		// name for listen port is device inode: on linux decimal and on darwin hexadecimal
-		if _, err := strconv.Atoi(conn.Self.Name); err == nil || conn.Self.Name[0:2] == "0x" { // listen socket
-			color = sockColor
+		a = hostArc
+		if isListenSocket(conn.Self.Name) {
+			a = sockArc
 		}
 	} else if conn.Peer.Pid >= math.MaxInt32 {
-		color = dataColor
-		if conn.Type != "REG" && conn.Type != "DIR" {
-			color = kernColor
+		a = dataArc
+		if isSharedMemory(conn) {
+			a = shmArc
+		} else if conn.Type != "REG" && conn.Type != "DIR" {
+			a = kernArc
 		}
 	} else {
-		color = procColor
+		a = procArc
 	}
-	return color
+	return a
 }
 
-// Nodegraph produces the process connections node graph.
-func Nodegraph(link string, queryPid Pid) backend.DataResponse {
+// isListenSocket reports whether the name of a socket's endpoint identifies a listen socket.
+// The name of a listen socket is its device inode: on linux decimal and on darwin hexadecimal.
+func isListenSocket(name string) bool {
+	if hex, ok := strings.CutPrefix(name, "0x"); ok {
+		_, err := strconv.ParseUint(hex, 16, 64)
+		return err == nil
+	}
+	_, err := strconv.ParseUint(name, 10, 64)
+	return err == nil
+}
+
+// arc sets the node's arc values for the arc that identifies its type.
+func arc(a int) []any {
+	values := make([]any, arcs)
+	for i := range values {
+		values[i] = 0.0
+	}
+	values[a] = 1.0
+	return values
+}
+
+// Nodegraph produces the process connections node graph. If the context is done before the graph is built,
+// the graph is partial.
+func Nodegraph(ctx context.Context, link string, model queryModel, settings dataSourceSettings) backend.DataResponse {
+	g := buildGraph(ctx, model, settings)
+	if settings.Anonymize {
+		g = anonymize(g)
+	}
 	return backend.DataResponse{
-		Frames: process.Nodegraph[[]any, any, []*data.Frame](Query{pid: queryPid, link: link}),
+		Frames: graphFrames(link, g, model.Streaming),
+	}
+}
+
+// graphFrames produces the frames of a graph, with the timings of its build if profiled.
+func graphFrames(link string, g graph, streaming bool) data.Frames {
+	frames := nodeFrames(link, g, streaming)
+	if g.timings != nil {
+		g.timings.mark("frames")
+		frames[0].Meta.Custom.(map[string]any)["timings"] = g.timings.observe()
 	}
+	return frames
+}
+
+// buildGraph collects the nodes and edges of the process connections node graph. gomon collects the process table
+// without a context, so the query's context bounds the assembly of the graph from the table, not its collection.
+func buildGraph(ctx context.Context, model queryModel, settings dataSourceSettings) graph {
+	graphLock.Lock()
+	defer graphLock.Unlock()
+	start := time.Now()
+	defer func() { nodegraphDuration.observe(time.Since(start)) }()
+	query := newQuery(ctx, model, settings, start)
+	query.live = settings.live()
+	if query.live {
+		query.rates, query.rtts, query.queues = sampleSockets()
+		if settings.Conntrack {
+			query.flows = conntrack()
+		}
+	}
+	if model.TreeOnly {
+		tb := query.table(false)
+		query.collected = time.Now()
+		return query.tree(tb)
+	}
+	tb := query.table(true)
+	query.collected = time.Now()
+	if query.live {
+		query.prevCPU, prevCPU = prevCPU, cpuTimes(tb)
+	}
+	return query.build(tb)
+}
+
+// newQuery creates the query of a graph build started at a time.
+func newQuery(ctx context.Context, model queryModel, settings dataSourceSettings, start time.Time) Query {
+	return Query{
+		ctx:        ctx,
+		model:      model,
+		settings:   settings,
+		containers: map[Pid]string{},
+		notices:    &notices{},
+		modes:      map[Pid]map[string]string{},
+		timings:    newTimings(settings, start),
+	}
+}
+
+// build builds the graph of a process table, centered on the query's seed if set.
+func (query Query) build(tb process.Table) graph {
+	tb = adoptOrphans(tb)
+	if query.model.SeedFile != "" || query.model.SeedHost != "" {
+		return query.seeded(tb)
+	}
+	return query.selected(tb)
 }
 
 // Pid returns the query's pid.
 func (query Query) Pid() Pid {
-	return query.pid
+	return query.model.Pid
 }
 
 // Arrow returns the character to use in edges' tooltip connections list.
@@ -82,6 +245,10 @@ func (query Query) Arrow() string {
 	return " -> "
 }
 
+// BuildGraph assembles the graph from the nodes and edges that gomon collected. For a stable layout across
+// refreshes, the order of the graph is deterministic: nodes by cluster (hosts, processes by depth, threads, datas),
+// and within a cluster by container if grouped, executable, and id; edges by source and target id; and the
+// connections of an edge with the parent first, then by text.
 func (query Query) BuildGraph(
 	tb process.Table,
 	itr process.Tree,
@@ -89,128 +256,493 @@ func (query Query) BuildGraph(
 	prcss map[int]map[Pid][]any,
 	datas map[Pid][]any,
 	edges map[[2]Pid][]any,
-) []*data.Frame {
+) graph {
+	query.timings.mark("collect")
+	processes := len(tb) // before transients are added
+	connections := 0
+	for _, p := range tb {
+		connections += len(p.Connections)
+	}
+	if query.live {
+		processCount.Store(int64(len(tb)))
+		if !query.model.TreeOnly { // the tree's connections are not collected
+			connectionCount.Store(int64(connections))
+		}
+		refreshed.Store(query.collected.UnixNano())
+		pruneExecutions(tb)
+		ready(tb)
+	}
+	if user, ok := restricted(tb); ok {
+		query.notices.add(data.NoticeSeverityWarning,
+			"only the processes of user %s are visible, run with elevated privileges for a full view", user)
+	}
+
+	query.unifyFiles(tb, datas, edges)
+	dropped := query.filter(tb, itr, hosts, datas, edges)
+
+	folded, roots := query.collapse(tb, itr, edges)
+
+	if query.model.ShowListeners {
+		query.listeners(tb, itr, folded, dropped, hosts, edges)
+	}
+	if query.model.SharedMem {
+		query.sharedMemory(tb, itr, folded, dropped, datas, edges)
+	}
+	if query.model.ShowSelfConnections {
+		query.selfConnections(tb, itr, folded, dropped, datas, edges)
+	}
+
 	maxConnections := 0
 
 	// add process nodes to each cluster, sort connections for tooltip
 	for depth, pid := range itr.All() {
+		if tb[pid] == nil { // process exited during collection
+			continue
+		}
+		if _, ok := folded[pid]; ok {
+			continue
+		}
+		if _, ok := dropped[pid]; ok {
+			continue
+		}
+		if query.expired() {
+			break
+		}
 		prcss[depth][pid] = query.ProcNode(tb[pid])
+		if c, ok := roots[pid]; ok {
+			prcss[depth][pid][3] = fmt.Sprintf("%s collapsing %d processes, %d connections",
+				tb[pid].Longname(), c.processes, c.connections)
+		}
 		for id, edge := range edges {
 			self := id[0]
 			peer := id[1]
 			if self == pid || self < 0 && peer == pid {
-				slices.SortFunc(edge[5:], func(a, b any) int { // tooltips list edge's connection endpoints
-					if strings.HasPrefix(a.(string), "parent") {
-						return -1
-					} else if strings.HasPrefix(b.(string), "parent") {
+				edge[directionDetail] = direction(tb, id)
+				edge[sentDetail], edge[receivedDetail] = query.rates.edge(tb, id)
+				edge[rttDetail] = query.rtts.edge(tb, id)
+				edge[flowBytesDetail], edge[flowPacketsDetail] = query.flows.edge(tb, id)
+				edge[sendQueueDetail], edge[recvQueueDetail] = query.queues.edge(tb, id)
+				slices.SortFunc(edge[connIndex:], func(a, b any) int { // tooltips list edge's connection endpoints
+					pa, pb := strings.HasPrefix(a.(string), "parent"), strings.HasPrefix(b.(string), "parent")
+					if pa != pb { // parent connection first
+						if pa {
+							return -1
+						}
 						return 1
-					} else {
-						return cmp.Compare(a.(string), b.(string))
 					}
+					return cmp.Compare(a.(string), b.(string))
 				})
-				if maxConnections < len(edge)-5 {
-					maxConnections = len(edge) - 5
+				if maxConnections < len(edge)-connIndex {
+					maxConnections = len(edge) - connIndex
+				}
+			}
+		}
+	}
+
+	if query.model.ShowTransients && query.live && !query.expired() {
+		tb = query.addTransients(tb, prcss, edges)
+	}
+
+	query.timings.mark("assemble")
+	if !query.expired() {
+		query.resolveHosts(hosts)
+	}
+	query.timings.mark("dns")
+
+	// add threads as children of their processes; connections remain with the process
+	thrds := map[Pid][]any{}
+	if query.model.Threads && query.live && !query.expired() {
+		for depth := range len(prcss) {
+			for pid := range prcss[depth] {
+				for tid, name := range threads(pid) {
+					thrds[tid] = query.threadNode(tb[pid], tid, name)
+					edges[[2]Pid{pid, tid}] = query.threadEdge(tb[pid], tid, name)
+					maxConnections = max(maxConnections, 1)
 				}
 			}
 		}
 	}
 
 	// build hosts cluster
-	ns := cluster(tb, hosts)
+	ns := query.cluster(tb, hosts)
 
 	// build processes clusters
 	for depth := range len(prcss) {
-		ns = append(ns, cluster(tb, prcss[depth])...)
+		ns = append(ns, query.cluster(tb, prcss[depth])...)
 	}
 
+	// build threads cluster
+	ns = append(ns, query.cluster(tb, thrds)...)
+
 	// build datas (files, sockets, pipes, ...) cluster
-	ns = append(ns, cluster(tb, datas)...)
+	ns = append(ns, query.cluster(tb, datas)...)
 
-	// add the edges
+	// add the edges, ordered by source and target
 	var es [][]any
-	// for id, edge := range edges { // does sorting improve graph consistency?
 	for _, edge := range gocore.Ordered(edges, func(a, b [2]Pid) int {
 		return cmp.Or(
 			cmp.Compare(a[0], b[0]),
 			cmp.Compare(a[1], b[1]),
 		)
 	}) {
+		query.label(edge)
 		es = append(es, edge)
 	}
 
-	return nodeFrames(query.link, ns, es, maxConnections)
+	g := graph{
+		timestamp:      time.Now(),
+		nodes:          ns,
+		edges:          es,
+		maxConnections: maxConnections,
+		processes:      processes,
+		connections:    connections,
+		collected:      query.collected,
+	}
+	if query.expired() {
+		dropDangling(&g)
+	}
+	query.truncate(&g)
+	query.size(tb, &g)
+	query.colorByName(&g)
+	query.stableIds(tb, &g)
+	g.notices = query.notices.list
+	query.timings.mark("assemble")
+	g.timings = query.timings
+
+	return g
 }
 
 func (query Query) HostNode(conn process.Connection) []any {
 	host, port, _ := net.SplitHostPort(conn.Peer.Name)
-	return append([]any{
+	node := append(append([]any{
 		int64(conn.Peer.Pid),
 		conn.Type + ":" + port,
-		gocore.Hostname(host),
+		host, // resolved to hostname by resolveHosts
 		host,
-	}, color(conn)...)
+	}, color(conn)...), pseudoDetails()...)
+	node[hostDetail] = host // resolved to hostname by resolveHosts
+	node[serviceDetail] = query.service(port)
+	node[sizeDetail] = defaultSize
+	return node
 }
 
 func (query Query) HostEdge(tb process.Table, conn process.Connection) []any {
-	host, _, _ := net.SplitHostPort(conn.Peer.Name)
-	return []any{
-		fmt.Sprintf("%d -> %d", conn.Peer.Pid, conn.Self.Pid),
-		int64(conn.Peer.Pid),
-		int64(conn.Self.Pid),
-		host,
-		tb[conn.Self.Pid].Shortname(),
-	}
+	host, port, _ := net.SplitHostPort(conn.Peer.Name)
+	_, selfPort, _ := net.SplitHostPort(conn.Self.Name)
+	edge := newEdge(conn.Peer.Pid, conn.Self.Pid, host, tb[conn.Self.Pid].Shortname(), conn.Type)
+	edge[peerPortDetail] = port
+	edge[encryptedDetail] = query.encrypted(port, selfPort)
+	return edge
 }
 
 func (query Query) DataNode(conn process.Connection) []any {
-	return append([]any{
+	node := append(append([]any{
 		int64(conn.Peer.Pid),
 		conn.Type,
 		conn.Peer.Name,
 		conn.Type + ":" + conn.Peer.Name,
-	}, color(conn)...)
+	}, color(conn)...), pseudoDetails()...)
+	node[hostDetail] = localHost()
+	node[sizeDetail] = defaultSize
+	if conn.Type == "BLK" || conn.Type == "CHR" {
+		node[deviceDetail] = device(conn.Peer.Name)
+	}
+	if query.isGPU(conn) {
+		copy(node[4:4+arcs], arc(gpuArc))
+	}
+	return node
 }
 
 func (query Query) DataEdge(tb process.Table, conn process.Connection) []any {
-	peer := conn.Type + ":" + conn.Peer.Name
-	return []any{
-		fmt.Sprintf("%d -> %d", conn.Self.Pid, conn.Peer.Pid),
-		int64(conn.Self.Pid),
-		int64(conn.Peer.Pid),
-		tb[conn.Self.Pid].Shortname(),
-		peer,
-	}
+	edge := newEdge(conn.Self.Pid, conn.Peer.Pid, tb[conn.Self.Pid].Shortname(), conn.Type+":"+conn.Peer.Name, conn.Type)
+	edge[modeDetail] = query.accessMode(tb, conn)
+	return edge
 }
 
 func (query Query) ProcNode(p *process.Process) []any {
-	return append([]any{
+	if query.live {
+		query.containers[p.Pid] = container(p.Pid)
+	}
+	longname := p.Longname()
+	if query.denied(p) {
+		longname = p.Shortname()
+	}
+	name := p.Id.Name
+	if !query.denied(p) {
+		name = displayName(p)
+	}
+	node := append(append([]any{
 		int64(p.Pid),
-		p.Id.Name,
+		name,
 		p.Pid.String(),
-		p.Longname(),
-	}, procColor...)
+		longname,
+	}, arc(procArc)...), query.details(p)...)
+	if node[warningsDetail] != "" {
+		warn(node, procArc)
+	}
+	return node
 }
 
 func (query Query) ProcEdge(tb process.Table, self, peer Pid) []any {
+	protocol, port, selfPort := "parent", "", ""
+	if conn, ok := connection(tb, self, peer); ok {
+		protocol = conn.Type
+		_, port, _ = net.SplitHostPort(conn.Peer.Name)
+		_, selfPort, _ = net.SplitHostPort(conn.Self.Name)
+	} else if conn, ok := connection(tb, peer, self); ok {
+		protocol = conn.Type
+		_, port, _ = net.SplitHostPort(conn.Self.Name)
+		_, selfPort, _ = net.SplitHostPort(conn.Peer.Name)
+	}
+	edge := newEdge(self, peer, tb[self].Shortname(), tb[peer].Shortname(), protocol)
+	edge[peerPortDetail] = port
+	edge[encryptedDetail] = query.encrypted(port, selfPort)
+	return edge
+}
+
+// accessMode reports whether a process opened a file to read, write, or both.
+func (query Query) accessMode(tb process.Table, conn process.Connection) string {
+	if conn.Type != "REG" && conn.Type != "DIR" {
+		return ""
+	}
+	modes, ok := query.modes[conn.Self.Pid]
+	if !ok && query.live && !query.denied(tb[conn.Self.Pid]) {
+		modes = accessModes(conn.Self.Pid)
+		query.modes[conn.Self.Pid] = modes
+	}
+	return modes[conn.Peer.Name]
+}
+
+// details returns the values of a process' detail fields, ordered as in nodeDetails.
+func (query Query) details(p *process.Process) []any {
+	var priority, nice int64
+	var policy, caps, seccomp, ns string
+	var nspid Pid
+	if query.live { // a replayed table's pids are not this system's
+		priority, nice, policy = scheduling(p.Pid)
+		caps, seccomp = capabilities(p.Pid)
+		ns, nspid = pidNamespace(p.Pid)
+	}
+	established, listening, remotes := socketCounts(p)
+	cl, reexec := "", ""
+	if !query.denied(p) {
+		cl = cmdline(p)
+		if query.live {
+			reexec = reexeced(p.Pid)
+		}
+	}
 	return []any{
-		fmt.Sprintf("%d -> %d", self, peer),
-		int64(self),
-		int64(peer),
-		tb[self].Shortname(),
-		tb[peer].Shortname(),
+		query.containers[p.Pid],
+		username(p),
+		cl,
+		priority,
+		nice,
+		policy,
+		reexec,
+		localHost(),
+		"",          // service of host nodes
+		defaultSize, // set by size
+		caps,
+		seccomp,
+		"", // device of data nodes
+		established,
+		listening,
+		remotes,
+		strings.Join(query.warnings(p, reexec, remotes), "; "),
+		query.group(p),
+		ns,
+		int64(nspid),
+		"", // lifetime of transient processes
+	}
+}
+
+// pseudoDetails returns the empty detail field values for host and data nodes.
+func pseudoDetails() []any {
+	values := make([]any, len(nodeDetails))
+	for i, detail := range nodeDetails {
+		switch detail.fieldType {
+		case data.FieldTypeString:
+			values[i] = ""
+		case data.FieldTypeFloat64:
+			values[i] = 0.0
+		case data.FieldTypeInt64:
+			values[i] = int64(0)
+		case data.FieldTypeBool:
+			values[i] = false
+		}
+	}
+	return values
+}
+
+// newEdge creates an edge between two nodes, with the defaults of the detail fields that follow its protocol.
+func newEdge(source, target Pid, mainStat, secondaryStat, protocol string) []any {
+	edge := append([]any{
+		fmt.Sprintf("%d -> %d", source, target),
+		int64(source),
+		int64(target),
+		mainStat,
+		secondaryStat,
+	}, make([]any, len(edgeDetails))...)
+	for i, detail := range edgeDetails {
+		switch detail.fieldType {
+		case data.FieldTypeString:
+			edge[5+i] = ""
+		case data.FieldTypeNullableFloat64:
+			edge[5+i] = (*float64)(nil)
+		case data.FieldTypeNullableInt64:
+			edge[5+i] = (*int64)(nil)
+		case data.FieldTypeNullableBool:
+			edge[5+i] = (*bool)(nil)
+		case data.FieldTypeNullableTime:
+			edge[5+i] = (*time.Time)(nil)
+		}
+	}
+	edge[protocolDetail] = protocol
+	return edge
+}
+
+// maxCmdline caps the length of the command line reported for a process.
+const maxCmdline = 256
+
+// cmdline reports the executable and arguments of a process, truncated on a rune boundary to maxCmdline.
+func cmdline(p *process.Process) string {
+	exe := p.Executable
+	if exe == "" {
+		exe = p.Id.Name
+	}
+	cl := strings.Join(append([]string{exe}, p.Args...), " ")
+	if len(cl) > maxCmdline {
+		n := maxCmdline - 3
+		for n > 0 && !utf8.RuneStart(cl[n]) {
+			n--
+		}
+		cl = cl[:n] + "..."
+	}
+	return cl
+}
+
+// localHost reports the name of the host of the processes, which groups the process, thread, and data nodes.
+var localHost = sync.OnceValue(func() string {
+	host, _ := os.Hostname()
+	return host
+})
+
+// displayName names a process by its executable's base name if its command name is likely truncated, i.e. at the
+// kernel's width and a prefix of the executable's name. The executable is read from /proc if gomon lacks it.
+func displayName(p *process.Process) string {
+	if len(p.Id.Name) != commWidth {
+		return p.Id.Name
+	}
+	exe := p.Executable
+	if exe == "" {
+		exe = executable(p.Pid)
+	}
+	if base := filepath.Base(exe); exe != "" && len(base) > commWidth && strings.HasPrefix(base, p.Id.Name) {
+		return base
 	}
+	return p.Id.Name
+}
+
+// username reports the owner of a process, or its uid if the name is not resolved.
+func username(p *process.Process) string {
+	if p.Username != "" {
+		return p.Username
+	}
+	return strconv.Itoa(p.UID)
+}
+
+func (query Query) threadNode(p *process.Process, tid Pid, name string) []any {
+	return append(append([]any{
+		int64(tid),
+		name,
+		tid.String(),
+		fmt.Sprintf("%s[%d] thread of %s", name, tid, p.Longname()),
+	}, arc(threadArc)...), query.details(p)...)
+}
+
+func (query Query) threadEdge(p *process.Process, tid Pid, name string) []any {
+	thread := fmt.Sprintf("%s[%d]", name, tid)
+	edge := newEdge(p.Pid, tid, p.Shortname(), thread, "thread")
+	edge[directionDetail] = "bidirectional"
+	return append(edge, "thread:"+p.Shortname()+query.Arrow()+thread)
+}
+
+// connection finds a connection of a process to a peer process.
+func connection(tb process.Table, self, peer Pid) (process.Connection, bool) {
+	if p := tb[self]; p != nil {
+		for _, conn := range p.Connections {
+			if conn.Peer.Pid == peer {
+				return conn, true
+			}
+		}
+	}
+	return process.Connection{}, false
+}
+
+// direction determines the flow of an edge's connections from the endpoint that accepted them.
+// An edge is inbound if its target accepted the connections from its source, and outbound if its source accepted them.
+// For host edges, the host is the source. Edges without accepting endpoints, such as pipes, are bidirectional.
+func direction(tb process.Table, id [2]Pid) string {
+	if id[0] < 0 { // host connection, flipped to show host to the left
+		for _, conn := range tb[id[1]].Connections {
+			if conn.Peer.Pid == id[0] {
+				if nodeArc(conn) == sockArc || listening(tb[id[1]], conn.Self.Name) {
+					return "inbound"
+				}
+				return "outbound"
+			}
+		}
+	} else if isProcess(id[1]) && tb[id[0]] != nil {
+		for _, conn := range tb[id[0]].Connections {
+			if conn.Peer.Pid == id[1] && (conn.Type == "TCP" || conn.Type == "UDP") {
+				if listening(tb[id[1]], conn.Peer.Name) {
+					return "inbound"
+				} else if listening(tb[id[0]], conn.Self.Name) {
+					return "outbound"
+				}
+			}
+		}
+	}
+	return "bidirectional"
+}
+
+// listening reports whether the port of a process' endpoint is one of its listen ports.
+func listening(p *process.Process, endpoint string) bool {
+	_, port, err := net.SplitHostPort(endpoint)
+	if p == nil || err != nil {
+		return false
+	}
+	for _, conn := range p.Connections {
+		if conn.Peer.Pid < 0 && nodeArc(conn) == sockArc {
+			if _, listen, err := net.SplitHostPort(conn.Peer.Name); err == nil && listen == port {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // cluster returns list of nodes in cluster and id of first node.
-func cluster(tb process.Table, nodes map[Pid][]any) [][]any {
+// If grouping by container, processes of a container, and then of a pid namespace, are ordered together.
+func (query Query) cluster(tb process.Table, nodes map[Pid][]any) [][]any {
 	if len(nodes) == 0 {
 		return [][]any{}
 	}
 
 	var ns [][]any
-	// for _, node := range nodes { // does sorting improve graph consistency?
 	for _, node := range gocore.Ordered(nodes, func(a, b Pid) int {
-		if a >= 0 && a < math.MaxInt32 { // processes
+		if isProcess(a) && tb[a] != nil && tb[b] != nil { // processes
+			if query.model.GroupByContainer {
+				if n := cmp.Compare(query.containers[a], query.containers[b]); n != 0 {
+					return n
+				}
+				if n := cmp.Compare(
+					nodes[a][namespaceDetail].(string),
+					nodes[b][namespaceDetail].(string),
+				); n != 0 {
+					return n
+				}
+			}
 			if n := cmp.Compare(
 				filepath.Base(tb[a].Executable),
 				filepath.Base(tb[b].Executable),
@@ -0,0 +1,73 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCmdlineTruncated(t *testing.T) {
+	for _, arg := range []string{
+		strings.Repeat("a", maxCmdline),
+		strings.Repeat("é", maxCmdline), // 2 byte runes
+		strings.Repeat("世", maxCmdline), // 3 byte runes
+		strings.Repeat("😀", maxCmdline), // 4 byte runes
+	} {
+		p := testProcess(10, 1, "cmd")
+		p.Args = []string{arg}
+		cl := cmdline(p)
+		if len(cl) > maxCmdline || !strings.HasSuffix(cl, "...") || !utf8.ValidString(cl) {
+			t.Errorf("cmdline of %d bytes %q is not a valid truncation", len(cl), cl)
+		}
+	}
+
+	p := testProcess(10, 1, "cmd")
+	p.Args = []string{"-v"}
+	if cl := cmdline(p); cl != "/usr/bin/cmd -v" {
+		t.Errorf("cmdline is %q, want %q", cl, "/usr/bin/cmd -v")
+	}
+}
+
+func TestIsListenSocket(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		want bool
+	}{
+		{"", false},
+		{"0", true},
+		{"x", false},
+		{"0x", false},
+		{"123456", true},             // linux inode
+		{"0xfffff80012345678", true}, // darwin address
+		{"0xnothex", false},
+		{"127.0.0.1:8080", false},
+		{"[::1]:8080", false},
+		{"-1", false},
+	} {
+		if got := isListenSocket(tt.name); got != tt.want {
+			t.Errorf("isListenSocket(%q) = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDisplayName(t *testing.T) {
+	long := "kube-controller-manager"
+	truncated := long[:commWidth]
+	for _, tt := range []struct {
+		name, exe, want string
+	}{
+		{"sshd", "/usr/sbin/sshd", "sshd"},
+		{truncated, "/usr/local/bin/" + long, long},
+		{truncated, "/usr/bin/" + truncated, truncated}, // not truncated, the name is exactly the width
+		{truncated, "/usr/bin/other-long-executable", truncated},
+		{long[:commWidth-1], "/usr/local/bin/" + long, long[:commWidth-1]}, // shorter than the width
+	} {
+		p := testProcess(10, 1, tt.name)
+		p.Executable = tt.exe
+		if got := displayName(p); got != tt.want {
+			t.Errorf("displayName(%q, %q) = %q, want %q", tt.name, tt.exe, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// maxNotices bounds the notices reported with a graph.
+	maxNotices = 10
+)
+
+type (
+	// notices collects the notices of degraded collection while a graph is built.
+	notices struct {
+		sync.Mutex
+		list []data.Notice
+	}
+)
+
+// add records a notice, ignoring duplicates and notices beyond maxNotices.
+func (n *notices) add(severity data.NoticeSeverity, format string, a ...any) {
+	n.Lock()
+	defer n.Unlock()
+	notice := data.Notice{Severity: severity, Text: fmt.Sprintf(format, a...)}
+	if len(n.list) < maxNotices && !slices.Contains(n.list, notice) {
+		n.list = append(n.list, notice)
+	}
+}
+
+// withNotice returns a copy of the graph with an additional notice, leaving the graph's notices unchanged.
+func (g graph) withNotice(severity data.NoticeSeverity, format string, a ...any) graph {
+	n := notices{list: slices.Clone(g.notices)}
+	n.add(severity, format, a...)
+	g.notices = n.list
+	return g
+}
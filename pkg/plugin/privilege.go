@@ -0,0 +1,26 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"os"
+
+	"github.com/zosmac/gomon/process"
+)
+
+// restricted reports the user whose processes are the only ones visible, which indicates that the collector lacks
+// the privileges to observe the processes of other users.
+func restricted(tb process.Table) (string, bool) {
+	if os.Geteuid() == 0 || len(tb) == 0 {
+		return "", false
+	}
+	var user *process.Process
+	for _, p := range tb {
+		if user == nil {
+			user = p
+		} else if p.UID != user.UID {
+			return "", false
+		}
+	}
+	return username(user), true
+}
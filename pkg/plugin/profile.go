@@ -0,0 +1,63 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"time"
+)
+
+type (
+	// timings records the durations of the phases of building a graph, when profiling is enabled.
+	timings struct {
+		last   time.Time
+		phases map[string]time.Duration
+	}
+)
+
+var (
+	// phaseDurations accumulates the times of the phases of building the node graph, by phase.
+	phaseDurations = map[string]*histogram{
+		"collect":  phaseHistogram("collect", "Time for gomon to build the process table and resolve connections."),
+		"assemble": phaseHistogram("assemble", "Time to filter and assemble the nodes and edges of the node graph."),
+		"dns":      phaseHistogram("dns", "Time to resolve the names of the node graph's hosts."),
+		"frames":   phaseHistogram("frames", "Time to format the node graph into data frames."),
+	}
+)
+
+// phaseHistogram defines the histogram of a phase of building the node graph.
+func phaseHistogram(phase, help string) *histogram {
+	return &histogram{
+		name:   "gomon_datasource_" + phase + "_duration_seconds",
+		help:   help + " Recorded when profiling.",
+		bounds: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		counts: make([]uint64, 10),
+	}
+}
+
+// newTimings starts recording the phases of building a graph if profiling is enabled.
+func newTimings(settings dataSourceSettings, start time.Time) *timings {
+	if !settings.Profile {
+		return nil
+	}
+	return &timings{last: start, phases: map[string]time.Duration{}}
+}
+
+// mark ends a phase, adding the time since the prior mark to the phase's duration.
+func (t *timings) mark(phase string) {
+	if t == nil {
+		return
+	}
+	now := time.Now()
+	t.phases[phase] += now.Sub(t.last)
+	t.last = now
+}
+
+// observe adds the phases' durations to their histograms, and reports them in milliseconds for the frames' metadata.
+func (t *timings) observe() map[string]float64 {
+	ms := map[string]float64{}
+	for phase, d := range t.phases {
+		phaseDurations[phase].observe(d)
+		ms[phase] = float64(d.Microseconds()) / 1000
+	}
+	return ms
+}
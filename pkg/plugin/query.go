@@ -0,0 +1,174 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// parseQuery unmarshals and validates the query model of a query.
+func parseQuery(raw json.RawMessage) (queryModel, error) {
+	model := queryModel{}
+	if err := json.Unmarshal(raw, &model); err != nil {
+		return model, fmt.Errorf("invalid query: %w", err)
+	}
+	model.Pid = pidOf(model.Pid) // node graph links report the stable id of the node
+	model.PinPids = pidsOf(model.PinPids)
+	model.ExcludePids = pidsOf(model.ExcludePids)
+	model.Collapse = pidsOf(model.Collapse)
+	if err := model.validate(); err != nil {
+		return model, fmt.Errorf("invalid query: %w", err)
+	}
+	return model, nil
+}
+
+// queryValues maps the parameters of a query string, named as the query model's JSON fields, onto a query model.
+// List parameters are comma separated.
+func queryValues(values url.Values) (queryModel, error) {
+	fields := map[string]any{}
+	t := reflect.TypeFor[queryModel]()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !values.Has(name) {
+			continue
+		}
+		value := values.Get(name)
+		if f.Type.Kind() == reflect.Slice {
+			list := []any{}
+			for _, v := range strings.Split(value, ",") {
+				elem, err := queryValue(f.Type.Elem(), v)
+				if err != nil {
+					return queryModel{}, fmt.Errorf("invalid query: %s: %w", name, err)
+				}
+				list = append(list, elem)
+			}
+			fields[name] = list
+		} else {
+			v, err := queryValue(f.Type, value)
+			if err != nil {
+				return queryModel{}, fmt.Errorf("invalid query: %s: %w", name, err)
+			}
+			fields[name] = v
+		}
+	}
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return queryModel{}, fmt.Errorf("invalid query: %w", err)
+	}
+	return parseQuery(raw)
+}
+
+// queryValue converts a query string parameter's value to the type of its query model field.
+func queryValue(t reflect.Type, value string) (any, error) {
+	switch t.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(value, 10, 64)
+	case reflect.Pointer:
+		return queryValue(t.Elem(), value)
+	default:
+		return value, nil
+	}
+}
+
+// validate checks the query model's options for consistency.
+// Pids of host and data pseudo-nodes (i.e. from node graph links) select the all processes graph.
+func (model queryModel) validate() error {
+	for i, protocol := range model.Protocols {
+		if strings.TrimSpace(protocol) == "" {
+			return fmt.Errorf("protocols[%d] is empty", i)
+		}
+	}
+	if model.MaxNodes < 0 {
+		return fmt.Errorf("maxNodes %d is negative", model.MaxNodes)
+	}
+	if model.MaxEdges < 0 {
+		return fmt.Errorf("maxEdges %d is negative", model.MaxEdges)
+	}
+	if model.Depth != nil && *model.Depth < 0 {
+		return fmt.Errorf("depth %d is negative", *model.Depth)
+	}
+	if _, err := filepath.Match(model.FileFilter, ""); err != nil {
+		return fmt.Errorf("fileFilter %q is not a valid pattern: %w", model.FileFilter, err)
+	}
+	if _, err := path.Match(model.Unit, ""); err != nil {
+		return fmt.Errorf("unit %q is not a valid pattern: %w", model.Unit, err)
+	}
+	if _, ok := sizeMetrics[model.SizeBy]; model.SizeBy != "" && !ok {
+		return fmt.Errorf("sizeBy %q is not one of connections, cpu, memory, or fds", model.SizeBy)
+	}
+	for _, pid := range model.PinPids {
+		if slices.Contains(model.ExcludePids, pid) {
+			return fmt.Errorf("pid %d is both pinned and excluded", pid)
+		}
+	}
+	if model.SeedFile != "" && model.SeedHost != "" {
+		return fmt.Errorf("seedFile and seedHost are both set")
+	}
+	for i, category := range model.FileTypes {
+		if !slices.Contains(fileCategories, category) {
+			return fmt.Errorf("fileTypes[%d] %q is not one of %s", i, category, strings.Join(fileCategories, ", "))
+		}
+	}
+	if _, err := filepath.Match(model.SeedFile, ""); err != nil {
+		return fmt.Errorf("seedFile %q is not a valid pattern: %w", model.SeedFile, err)
+	}
+	for i, pid := range model.Collapse {
+		if !isProcess(pid) || pid == 0 {
+			return fmt.Errorf("collapse[%d] pid %d is not a process", i, pid)
+		}
+	}
+	return nil
+}
+
+// querySchema describes the query model's fields as a JSON schema.
+func querySchema() map[string]any {
+	properties := map[string]any{}
+	t := reflect.TypeFor[queryModel]()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		properties[name] = schemaType(f.Type)
+	}
+	return map[string]any{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"type":       "object",
+		"properties": properties,
+	}
+}
+
+// schemaType maps a Go type to its JSON schema type.
+func schemaType(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Pointer:
+		return schemaType(t.Elem())
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaType(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaType(t.Elem())}
+	default:
+		return map[string]any{"type": "object"}
+	}
+}
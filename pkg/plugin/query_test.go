@@ -0,0 +1,46 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestParseQueryPids(t *testing.T) {
+	stable := Pid(1234)<<32 | 20 // a node's id qualified by its process' start time
+	model, err := parseQuery(fmt.Appendf(nil, `{"pid":%d,"pinPids":[%d,30],"excludePids":[%d],"collapse":[%d]}`,
+		stable, stable, stable+1, stable))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.Pid != 20 {
+		t.Errorf("pid %d, want 20", model.Pid)
+	}
+	if !slices.Equal(model.PinPids, []Pid{20, 30}) {
+		t.Errorf("pinPids %v, want [20 30]", model.PinPids)
+	}
+	if !slices.Equal(model.ExcludePids, []Pid{21}) {
+		t.Errorf("excludePids %v, want [21]", model.ExcludePids)
+	}
+	if !slices.Equal(model.Collapse, []Pid{20}) {
+		t.Errorf("collapse %v, want [20]", model.Collapse)
+	}
+}
+
+func TestParseQueryNodeLinks(t *testing.T) {
+	for _, id := range []Pid{fileBase + 20, selfBase | 20, closedBase | 20, math.MaxInt32 + 20, -20} {
+		model, err := parseQuery(fmt.Appendf(nil, `{"pid":%d,"pinPids":[%d,30]}`, id, id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if model.Pid != 0 {
+			t.Errorf("link of node %#x selects pid %d, want none", id, model.Pid)
+		}
+		if !slices.Equal(model.PinPids, []Pid{30}) {
+			t.Errorf("pinPids of node %#x are %v, want [30]", id, model.PinPids)
+		}
+	}
+}
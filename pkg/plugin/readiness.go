@@ -0,0 +1,96 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zosmac/gomon/process"
+)
+
+const (
+	// readinessTimeout limits how long a query waits for the collector's first scan.
+	readinessTimeout = 5 * time.Second
+
+	// readinessInterval limits how often the process table is built to check the collector's readiness.
+	readinessInterval = 250 * time.Millisecond
+
+	// warmupPeriod limits how long after startup a query may report the collector warming up. A host whose
+	// processes have no connections, or without lsof, is then considered ready rather than blocking every query.
+	warmupPeriod = 30 * time.Second
+)
+
+var (
+	// started records the plugin's startup time.
+	started = time.Now()
+
+	// collectorReady records that the collector has reported the processes' connections.
+	collectorReady atomic.Bool
+
+	// readinessChecked records when checkReady last built the process table, shared by all waiting queries.
+	readinessChecked struct {
+		sync.Mutex
+		time.Time
+	}
+)
+
+// ready reports whether the collector has reported the processes' connections. The lsof scan that gomon
+// collects connections from completes some time after startup, so until then the graph lacks connections.
+func ready(tb process.Table) bool {
+	if collectorReady.Load() {
+		return true
+	}
+	if time.Since(started) > warmupPeriod {
+		collectorReady.Store(true)
+		return true
+	}
+	for _, p := range tb {
+		if len(p.Connections) > 0 {
+			collectorReady.Store(true)
+			return true
+		}
+	}
+	return false
+}
+
+// checkReady reports whether the collector is ready. However many queries and health checks ask,
+// it builds the process table under graphLock at most once per readiness interval,
+// and otherwise reports the cached result.
+func checkReady() bool {
+	if collectorReady.Load() {
+		return true
+	}
+	readinessChecked.Lock()
+	defer readinessChecked.Unlock()
+	if time.Since(readinessChecked.Time) < readinessInterval {
+		return ready(nil)
+	}
+	readinessChecked.Time = time.Now()
+	return ready(lockedTable(true))
+}
+
+// awaitReady waits up to the readiness timeout for the collector to report the processes' connections.
+func awaitReady(ctx context.Context) bool {
+	if checkReady() {
+		return true
+	}
+	t := time.NewTicker(readinessInterval)
+	defer t.Stop()
+	timeout := time.NewTimer(readinessTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timeout.C:
+			return false
+		case <-t.C:
+		}
+		if checkReady() {
+			return true
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckReadyCached(t *testing.T) {
+	defer func(s time.Time) { started = s }(started)
+	defer collectorReady.Store(collectorReady.Load())
+
+	started = time.Now()
+	collectorReady.Store(false)
+	readinessChecked.Time = time.Now() // a check just built the table without connections
+	if checkReady() {
+		t.Error("cached check reports ready before the collector reported connections")
+	}
+
+	started = time.Now().Add(-warmupPeriod - time.Second)
+	if !checkReady() {
+		t.Error("cached check reports not ready after the warmup period")
+	}
+}
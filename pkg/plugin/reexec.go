@@ -0,0 +1,73 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"sync"
+
+	"github.com/zosmac/gomon/process"
+)
+
+type (
+	// execution records the executable a process was observed running.
+	execution struct {
+		start      int64  // distinguishes a reused pid
+		executable string // the executable first observed
+		reexeced   string // the executable the process exec'd to, until acknowledged
+	}
+)
+
+var (
+	// executions records the executables of processes across graph builds, to detect exec without fork.
+	executions = struct {
+		sync.Mutex
+		m map[Pid]execution
+	}{m: map[Pid]execution{}}
+)
+
+// reexeced reports the executable a process was first observed running if it has since exec'd another.
+// The executable is read anew as gomon caches each pid's command line.
+func reexeced(pid Pid) string {
+	exe := executable(pid)
+	if exe == "" {
+		return ""
+	}
+	start := startTime(pid)
+
+	executions.Lock()
+	defer executions.Unlock()
+	e, ok := executions.m[pid]
+	if !ok || e.start != start {
+		executions.m[pid] = execution{start: start, executable: exe}
+		return ""
+	}
+	if exe != e.executable {
+		e.executable, e.reexeced = exe, e.executable
+		executions.m[pid] = e
+	}
+	return e.reexeced
+}
+
+// acknowledge clears the re-exec flag of a process, reporting whether it was flagged.
+func acknowledge(pid Pid) bool {
+	executions.Lock()
+	defer executions.Unlock()
+	e, ok := executions.m[pid]
+	if !ok || e.reexeced == "" {
+		return false
+	}
+	e.reexeced = ""
+	executions.m[pid] = e
+	return true
+}
+
+// pruneExecutions forgets the executables of processes no longer in the process table.
+func pruneExecutions(tb process.Table) {
+	executions.Lock()
+	defer executions.Unlock()
+	for pid := range executions.m {
+		if tb[pid] == nil {
+			delete(executions.m, pid)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/zosmac/gomon/process"
+)
+
+const (
+	// remoteTimeout limits how long fetching a remote host's process table may take.
+	remoteTimeout = 10 * time.Second
+
+	// maxRemoteTable limits the size of a remote host's process table, so that a misbehaving peer cannot exhaust
+	// the plugin's memory. A table of tens of thousands of processes and their connections fits well within it.
+	maxRemoteTable = 64 << 20
+)
+
+// validateRemote checks that the remote URL, if set, is an http or https URL, and is not set with a replay file.
+func (settings dataSourceSettings) validateRemote() error {
+	if settings.RemoteURL == "" {
+		return nil
+	}
+	if settings.ReplayFile != "" {
+		return errors.New("remoteURL and replayFile are exclusive")
+	}
+	u, err := url.Parse(settings.RemoteURL)
+	if err != nil {
+		return fmt.Errorf("remoteURL %q: %w", settings.RemoteURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("remoteURL %q is not an http or https URL", settings.RemoteURL)
+	}
+	return nil
+}
+
+// live reports whether the settings graph the live system rather than a remote host's or a replayed table.
+func (settings dataSourceSettings) live() bool {
+	return settings.RemoteURL == "" && settings.ReplayFile == ""
+}
+
+// fetchTable fetches the process table of a remote host from the table resource of the gomon datasource there,
+// authorizing the request with the remote token if set. A table larger than maxRemoteTable is an error.
+func fetchTable(ctx context.Context, settings dataSourceSettings) (process.Table, error) {
+	ctx, cancel := context.WithTimeout(ctx, remoteTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, settings.RemoteURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if settings.remoteToken != "" {
+		req.Header.Set("Authorization", "Bearer "+settings.remoteToken)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", settings.RemoteURL, resp.Status)
+	}
+	buf, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteTable+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) > maxRemoteTable {
+		return nil, fmt.Errorf("%s: table exceeds %d MiB", settings.RemoteURL, maxRemoteTable>>20)
+	}
+	tb, err := decodeTable(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", settings.RemoteURL, err)
+	}
+	return tb, nil
+}
@@ -0,0 +1,93 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/zosmac/gomon/process"
+)
+
+func TestFetchTable(t *testing.T) {
+	entries := []tableEntry{
+		{Pid: 1, Name: "init"},
+		{Pid: 20, Ppid: 1, Name: "server", Connections: []process.Connection{
+			testConnection("TCP", 20, "10.0.0.2:443", -3, "10.0.0.9:51234"),
+		}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(entries)
+	}))
+	defer server.Close()
+
+	settings := dataSourceSettings{RemoteURL: server.URL + "/table", remoteToken: "token"}
+	if err := settings.validateRemote(); err != nil {
+		t.Fatal(err)
+	}
+	if settings.live() {
+		t.Error("remote settings graph the live system")
+	}
+	tb, err := fetchTable(context.Background(), settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tb) != 2 || tb[20].Id.Name != "server" || len(tb[20].Connections) != 1 {
+		t.Errorf("remote table not decoded: %v", tb)
+	}
+
+	settings.remoteToken = ""
+	if _, err := fetchTable(context.Background(), settings); err == nil {
+		t.Error("unauthorized fetch reports no error")
+	}
+}
+
+func TestValidateRemote(t *testing.T) {
+	for _, settings := range []dataSourceSettings{
+		{RemoteURL: "ftp://host/table"},
+		{RemoteURL: "http:///table"},
+		{RemoteURL: "http://host/table", ReplayFile: "capture.json"},
+	} {
+		if err := settings.validateRemote(); err == nil {
+			t.Errorf("remote URL %q with replay file %q is valid", settings.RemoteURL, settings.ReplayFile)
+		}
+	}
+}
+
+func TestFetchTableLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/large":
+			w.Write([]byte("["))
+			w.Write(bytes.Repeat([]byte(" "), maxRemoteTable))
+			w.Write([]byte("]"))
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	if _, err := fetchTable(context.Background(), dataSourceSettings{RemoteURL: server.URL + "/large"}); err == nil ||
+		!strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("oversized table reports %v", err)
+	}
+	if _, err := fetchTable(context.Background(), dataSourceSettings{RemoteURL: server.URL + "/empty"}); err == nil {
+		t.Error("fetch without a table reports no error")
+	}
+
+	query := testQuery(queryModel{}, dataSourceSettings{RemoteURL: server.URL + "/large"})
+	if tb := query.table(true); len(tb) != 0 || len(query.notices.list) != 1 ||
+		query.notices.list[0].Severity != data.NoticeSeverityError {
+		t.Errorf("oversized table notices %v", query.notices.list)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/zosmac/gomon/process"
+)
+
+// loadTable reconstructs a process table from a capture file. The capture format is the table resource's JSON,
+// whose processes' connections are already resolved to their peers.
+func loadTable(path string) (process.Table, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tb, err := decodeTable(buf)
+	if err != nil {
+		return nil, fmt.Errorf("capture %s: %w", path, err)
+	}
+	return tb, nil
+}
+
+// decodeTable reconstructs a process table from the table resource's JSON.
+func decodeTable(buf []byte) (process.Table, error) {
+	var entries []tableEntry
+	if err := json.Unmarshal(buf, &entries); err != nil {
+		return nil, err
+	}
+
+	tb := process.Table{}
+	for _, entry := range entries {
+		if _, ok := tb[entry.Pid]; ok {
+			return nil, fmt.Errorf("pid %d is duplicated", entry.Pid)
+		}
+		p := &process.Process{}
+		p.Pid = entry.Pid
+		p.Id.Name = entry.Name
+		p.Ppid = entry.Ppid
+		p.Executable = entry.Executable
+		p.Username = entry.User
+		p.Connections = entry.Connections
+		tb[entry.Pid] = p
+	}
+	return tb, nil
+}
+
+// validateReplay checks that the replay file, if set, is a loadable capture.
+func (settings dataSourceSettings) validateReplay() error {
+	if settings.ReplayFile == "" {
+		return nil
+	}
+	_, err := loadTable(settings.ReplayFile)
+	return err
+}
+
+// table builds the process table from the live system, fetches it from the remote host, or loads it from the
+// replay file. The live table's connections are collected if requested, while the others' are always present.
+func (query Query) table(connections bool) process.Table {
+	switch {
+	case query.settings.RemoteURL != "":
+		tb, err := fetchTable(query.ctx, query.settings)
+		if err != nil {
+			query.notices.add(data.NoticeSeverityError, "remote table not fetched: %v", err)
+			return process.Table{}
+		}
+		return tb
+	case query.settings.ReplayFile != "":
+		tb, err := loadTable(query.settings.ReplayFile)
+		if err != nil {
+			query.notices.add(data.NoticeSeverityError, "replay file not loaded: %v", err)
+			return process.Table{}
+		}
+		return tb
+	}
+	tb := process.BuildTable()
+	if connections {
+		process.Connections(tb)
+	}
+	return tb
+}
@@ -0,0 +1,431 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/zosmac/gomon/process"
+)
+
+type (
+	// pidEntry reports a process for selecting the pid of a query.
+	pidEntry struct {
+		Pid        Pid    `json:"pid"`
+		Name       string `json:"name"`
+		Executable string `json:"executable"`
+		Ppid       Pid    `json:"ppid"`
+		User       string `json:"user"`
+	}
+
+	// tableEntry reports a process of the process table.
+	tableEntry struct {
+		Pid         Pid                  `json:"pid"`
+		Ppid        Pid                  `json:"ppid"`
+		Name        string               `json:"name"`
+		Executable  string               `json:"executable"`
+		User        string               `json:"user"`
+		Established int64                `json:"established"`
+		Listening   int64                `json:"listening"`
+		RemoteHosts int64                `json:"remoteHosts"`
+		Connections []process.Connection `json:"connections"`
+	}
+)
+
+var (
+	// resources maps the method and path of each data source resource to its handler.
+	resources = map[[2]string]func(*Instance, context.Context, *backend.CallResourceRequest) *backend.CallResourceResponse{
+		{http.MethodGet, "metrics"}:      (*Instance).metricsResource,
+		{http.MethodGet, "query-schema"}: (*Instance).querySchemaResource,
+		{http.MethodGet, "table"}:        (*Instance).tableResource,
+		{http.MethodPost, "acknowledge"}: (*Instance).acknowledgeResource,
+		{http.MethodGet, "graph"}:        (*Instance).graphResource,
+		{http.MethodGet, "nodes.csv"}:    (*Instance).nodesCSVResource,
+		{http.MethodGet, "edges.csv"}:    (*Instance).edgesCSVResource,
+		{http.MethodGet, "changes"}:      (*Instance).changesResource,
+		{http.MethodGet, "pids"}:         (*Instance).pidsResource,
+		{http.MethodGet, "snapshots"}:    (*Instance).snapshotsResource,
+	}
+)
+
+// jsonResponse formats a resource response body as JSON.
+func jsonResponse(v any) *backend.CallResourceResponse {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return &backend.CallResourceResponse{
+			Status: http.StatusInternalServerError,
+			Body:   []byte(err.Error()),
+		}
+	}
+	return &backend.CallResourceResponse{
+		Status: http.StatusOK,
+		Headers: map[string][]string{
+			"Content-Type": {"application/json"},
+		},
+		Body: body,
+	}
+}
+
+// metricsResource reports the collector internals in Prometheus text format.
+func (instance *Instance) metricsResource(context.Context, *backend.CallResourceRequest) *backend.CallResourceResponse {
+	return &backend.CallResourceResponse{
+		Status: http.StatusOK,
+		Headers: map[string][]string{
+			"Content-Type": {"text/plain; version=0.0.4"},
+		},
+		Body: metrics(),
+	}
+}
+
+// querySchemaResource reports the fields and types of the query model.
+func (instance *Instance) querySchemaResource(context.Context, *backend.CallResourceRequest) *backend.CallResourceResponse {
+	return jsonResponse(querySchema())
+}
+
+// lockedTable builds the process table, optionally with each process' connections, under graphLock,
+// as the process package retains state between builds.
+func lockedTable(connections bool) process.Table {
+	graphLock.Lock()
+	defer graphLock.Unlock()
+	tb := process.BuildTable()
+	if connections {
+		process.Connections(tb)
+	}
+	return tb
+}
+
+// tableResource reports the process table with each process' connections. As for the graph resource, the snapshot
+// parameter selects a retained snapshot's table, and a past to parameter the table of the snapshot closest to it.
+func (instance *Instance) tableResource(_ context.Context, req *backend.CallResourceRequest) *backend.CallResourceResponse {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return &backend.CallResourceResponse{Status: http.StatusBadRequest, Body: []byte(err.Error())}
+	}
+	_, to, err := timeRange(u.Query())
+	if err != nil {
+		return &backend.CallResourceResponse{Status: http.StatusBadRequest, Body: []byte(err.Error())}
+	}
+	var tb process.Table
+	if u.Query().Has("snapshot") {
+		snap, resp := instance.snapshotAt(u.Query().Get("snapshot"))
+		if resp != nil {
+			return resp
+		}
+		tb = snap.tb
+	} else if snap, ok := instance.pastSnapshot(to); ok {
+		tb = snap.tb
+	} else {
+		tb = lockedTable(true)
+	}
+
+	entries := make([]tableEntry, 0, len(tb))
+	for _, p := range tb {
+		established, listening, remotes := socketCounts(p)
+		entry := tableEntry{
+			Pid:         p.Pid,
+			Ppid:        p.Ppid,
+			Name:        p.Id.Name,
+			Executable:  p.Executable,
+			User:        username(p),
+			Established: established,
+			Listening:   listening,
+			RemoteHosts: remotes,
+			Connections: p.Connections,
+		}
+		if instance.settings.denied(p) {
+			entry.Executable = "" // as in the denied process' node
+		}
+		if instance.settings.Anonymize {
+			entry = anonymizeTableEntry(entry)
+		}
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b tableEntry) int {
+		return cmp.Compare(a.Pid, b.Pid)
+	})
+
+	return jsonResponse(entries)
+}
+
+// acknowledgeResource clears the re-exec flag of the process identified by the pid query parameter.
+func (instance *Instance) acknowledgeResource(_ context.Context, req *backend.CallResourceRequest) *backend.CallResourceResponse {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return &backend.CallResourceResponse{Status: http.StatusBadRequest, Body: []byte(err.Error())}
+	}
+	pid, err := strconv.Atoi(u.Query().Get("pid"))
+	if err != nil {
+		return &backend.CallResourceResponse{Status: http.StatusBadRequest, Body: []byte("invalid pid")}
+	}
+	return jsonResponse(map[string]bool{"acknowledged": acknowledge(pidOf(Pid(pid)))})
+}
+
+// graphResource reports the node graph's frames as data frame JSON.
+// The query string parameters are the query model's fields, e.g. /graph?pid=1&threads=true&protocols=TCP,UDP.
+// The snapshot parameter selects a retained snapshot by its timestamp, as listed by the snapshots resource, whose graph
+// is built with the other parameters. The from and to parameters are the time range of a query, selecting the
+// snapshots of a window or the snapshot closest to a past time.
+func (instance *Instance) graphResource(ctx context.Context, req *backend.CallResourceRequest) *backend.CallResourceResponse {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return &backend.CallResourceResponse{Status: http.StatusBadRequest, Body: []byte(err.Error())}
+	}
+	model, err := queryValues(u.Query())
+	if err != nil {
+		return &backend.CallResourceResponse{Status: http.StatusBadRequest, Body: []byte(err.Error())}
+	}
+	g, resp := instance.selection(ctx, u.Query(), model)
+	if resp != nil {
+		return resp
+	}
+	return jsonResponse(graphFrames("", g, model.Streaming))
+}
+
+// warming reports the response for a resource request while the live collector warms up, as a query reports it.
+func (instance *Instance) warming(ctx context.Context) *backend.CallResourceResponse {
+	if !instance.settings.live() || awaitReady(ctx) {
+		return nil
+	}
+	return &backend.CallResourceResponse{
+		Status: http.StatusServiceUnavailable,
+		Body:   []byte("collector warming up, retry shortly"),
+	}
+}
+
+// snapshotAt looks up the retained snapshot of a timestamp, or reports the response for the failed lookup.
+func (instance *Instance) snapshotAt(timestamp string) (snapshot, *backend.CallResourceResponse) {
+	ts, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return snapshot{}, &backend.CallResourceResponse{
+			Status: http.StatusBadRequest,
+			Body:   []byte("invalid snapshot timestamp"),
+		}
+	}
+	if instance.snapshots == nil {
+		return snapshot{}, &backend.CallResourceResponse{
+			Status: http.StatusNotFound,
+			Body:   []byte("snapshots are not retained"),
+		}
+	}
+	snap, ok := instance.snapshots.at(ts)
+	if !ok {
+		return snapshot{}, &backend.CallResourceResponse{
+			Status: http.StatusNotFound,
+			Body:   []byte("snapshot " + ts.Format(time.RFC3339Nano) + " is not retained"),
+		}
+	}
+	return snap, nil
+}
+
+// snapshot builds the graph of the retained snapshot of a timestamp with the query's options,
+// or reports the response for the failed lookup.
+func (instance *Instance) snapshot(
+	ctx context.Context,
+	timestamp string,
+	model queryModel,
+) (graph, *backend.CallResourceResponse) {
+	snap, resp := instance.snapshotAt(timestamp)
+	if resp != nil {
+		return graph{}, resp
+	}
+	qctx, cancel := instance.settings.queryContext(ctx)
+	defer cancel()
+	g := snap.build(qctx, model, instance.settings)
+	if instance.settings.Anonymize {
+		g = anonymize(g)
+	}
+	return g, nil
+}
+
+// nodesCSVResource reports the node graph's nodes as CSV, for the same query string parameters as the graph resource.
+func (instance *Instance) nodesCSVResource(ctx context.Context, req *backend.CallResourceRequest) *backend.CallResourceResponse {
+	return instance.csvResource(ctx, req, nodesCSV)
+}
+
+// edgesCSVResource reports the node graph's edges as CSV, for the same query string parameters as the graph resource.
+func (instance *Instance) edgesCSVResource(ctx context.Context, req *backend.CallResourceRequest) *backend.CallResourceResponse {
+	return instance.csvResource(ctx, req, edgesCSV)
+}
+
+// timeRange parses the from and to parameters of a resource request, RFC 3339 timestamps that default to the
+// last 5 minutes, as a query's time range does.
+func timeRange(values url.Values) (time.Time, time.Time, error) {
+	to := time.Now()
+	if values.Has("to") {
+		t, err := time.Parse(time.RFC3339Nano, values.Get("to"))
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to timestamp: %w", err)
+		}
+		to = t
+	}
+	from := to.Add(-5 * time.Minute)
+	if values.Has("from") {
+		t, err := time.Parse(time.RFC3339Nano, values.Get("from"))
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from timestamp: %w", err)
+		}
+		from = t
+	}
+	return from, to, nil
+}
+
+// selection builds the graph that a resource request selects as a query would: the graph of a snapshot, of the
+// union of a window's snapshots, of the snapshot closest to a past time, or of the live system.
+func (instance *Instance) selection(
+	ctx context.Context,
+	values url.Values,
+	model queryModel,
+) (graph, *backend.CallResourceResponse) {
+	if values.Has("snapshot") {
+		return instance.snapshot(ctx, values.Get("snapshot"), model)
+	}
+	from, to, err := timeRange(values)
+	if err != nil {
+		return graph{}, &backend.CallResourceResponse{Status: http.StatusBadRequest, Body: []byte(err.Error())}
+	}
+
+	qctx, cancel := instance.settings.queryContext(ctx)
+	defer cancel()
+	var g graph
+	if model.Window {
+		if instance.snapshots == nil {
+			return graph{}, &backend.CallResourceResponse{
+				Status: http.StatusBadRequest,
+				Body:   []byte("window queries require snapshot retention"),
+			}
+		}
+		g = instance.snapshots.union(qctx, model, instance.settings, from, to)
+	} else if snap, ok := instance.pastSnapshot(to); ok {
+		g = snap.build(qctx, model, instance.settings)
+	} else {
+		if resp := instance.warming(ctx); resp != nil {
+			return graph{}, resp
+		}
+		g = buildGraph(qctx, model, instance.settings)
+	}
+	if instance.settings.Anonymize {
+		g = anonymize(g)
+	}
+	return g, nil
+}
+
+// pastSnapshot returns the retained snapshot closest to a time older than the snapshot interval.
+func (instance *Instance) pastSnapshot(t time.Time) (snapshot, bool) {
+	if instance.snapshots == nil || time.Since(t) <= instance.snapshots.interval {
+		return snapshot{}, false
+	}
+	return instance.snapshots.closest(t)
+}
+
+// csvResource builds the node graph of a CSV resource request, or selects its snapshots, and formats it as CSV.
+func (instance *Instance) csvResource(
+	ctx context.Context,
+	req *backend.CallResourceRequest,
+	format func(graph) ([]byte, error),
+) *backend.CallResourceResponse {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return &backend.CallResourceResponse{Status: http.StatusBadRequest, Body: []byte(err.Error())}
+	}
+	model, err := queryValues(u.Query())
+	if err != nil {
+		return &backend.CallResourceResponse{Status: http.StatusBadRequest, Body: []byte(err.Error())}
+	}
+
+	g, resp := instance.selection(ctx, u.Query(), model)
+	if resp != nil {
+		return resp
+	}
+
+	body, err := format(g)
+	if err != nil {
+		return &backend.CallResourceResponse{Status: http.StatusInternalServerError, Body: []byte(err.Error())}
+	}
+	return &backend.CallResourceResponse{
+		Status: http.StatusOK,
+		Headers: map[string][]string{
+			"Content-Type": {"text/csv; charset=utf-8"},
+		},
+		Body: body,
+	}
+}
+
+// snapshotsResource reports the timestamps of the retained snapshots, oldest first.
+func (instance *Instance) snapshotsResource(context.Context, *backend.CallResourceRequest) *backend.CallResourceResponse {
+	if instance.snapshots == nil {
+		return jsonResponse([]time.Time{})
+	}
+	return jsonResponse(instance.snapshots.timestamps())
+}
+
+// changesResource reports the processes and connections that appeared or exited since the prior request.
+// The first request records the baseline.
+func (instance *Instance) changesResource(context.Context, *backend.CallResourceRequest) *backend.CallResourceResponse {
+	curr := newTableState(lockedTable(true), instance.settings)
+
+	lastTable.Lock()
+	prev := lastTable.state
+	lastTable.state = curr
+	lastTable.Unlock()
+
+	if prev == nil {
+		return jsonResponse(changes{To: curr.timestamp, Baseline: true})
+	}
+	c := diffTables(prev, curr)
+	if instance.settings.Anonymize {
+		c = anonymizeChanges(c)
+	}
+	return jsonResponse(c)
+}
+
+// pidsResource reports the processes of the process table, sorted by name, for the query editor's pid selection.
+// The filter query parameter selects the processes whose name, executable, or pid contains it.
+func (instance *Instance) pidsResource(_ context.Context, req *backend.CallResourceRequest) *backend.CallResourceResponse {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return &backend.CallResourceResponse{Status: http.StatusBadRequest, Body: []byte(err.Error())}
+	}
+	filter := u.Query().Get("filter")
+
+	entries := []pidEntry{}
+	for _, p := range lockedTable(false) {
+		entry := pidEntry{
+			Pid:        p.Pid,
+			Name:       p.Id.Name,
+			Executable: p.Executable,
+			Ppid:       p.Ppid,
+			User:       username(p),
+		}
+		if instance.settings.denied(p) {
+			entry.Executable = "" // as in the denied process' node
+		}
+		if instance.settings.Anonymize {
+			entry = anonymizePidEntry(entry) // filter on the tokens, so names cannot be probed
+		}
+		if filter != "" &&
+			!strings.Contains(entry.Name, filter) &&
+			!strings.Contains(entry.Executable, filter) &&
+			!strings.Contains(entry.Pid.String(), filter) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b pidEntry) int {
+		return cmp.Or(
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.Pid, b.Pid),
+		)
+	})
+
+	return jsonResponse(entries)
+}
@@ -0,0 +1,91 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestSnapshotResourceOptions(t *testing.T) {
+	instance := &Instance{snapshots: newSnapshots(1, minSnapshotInterval)}
+	instance.snapshots.add(snapshotTable())
+	ts := instance.snapshots.timestamps()[0].Format(time.RFC3339Nano)
+
+	resp := instance.nodesCSVResource(context.Background(), &backend.CallResourceRequest{
+		URL: "nodes.csv?" + url.Values{"snapshot": {ts}, "pid": {"20"}}.Encode(),
+	})
+	if resp.Status != http.StatusOK {
+		t.Fatalf("status %d: %s", resp.Status, resp.Body)
+	}
+	body := string(resp.Body)
+	if !strings.Contains(body, "worker") || strings.Contains(body, "client") {
+		t.Errorf("snapshot nodes are not pid 20's family:\n%s", body)
+	}
+}
+
+func TestGraphResourceCancelled(t *testing.T) {
+	instance := &Instance{snapshots: newSnapshots(1, minSnapshotInterval)}
+	instance.snapshots.add(snapshotTable())
+	ts := instance.snapshots.timestamps()[0].Format(time.RFC3339Nano)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // the request was abandoned
+	resp := instance.graphResource(ctx, &backend.CallResourceRequest{
+		URL: "graph?" + url.Values{"snapshot": {ts}}.Encode(),
+	})
+	if resp.Status != http.StatusOK {
+		t.Fatalf("status %d: %s", resp.Status, resp.Body)
+	}
+	if !strings.Contains(string(resp.Body), "graph is partial") {
+		t.Errorf("graph of an abandoned request is not partial:\n%s", resp.Body)
+	}
+}
+
+func TestResourceSelection(t *testing.T) {
+	instance := &Instance{snapshots: newSnapshots(1, minSnapshotInterval)}
+	instance.snapshots.add(snapshotTable())
+	ts := instance.snapshots.timestamps()[0]
+
+	resp := instance.edgesCSVResource(context.Background(), &backend.CallResourceRequest{
+		URL: "edges.csv?" + url.Values{
+			"window": {"true"},
+			"from":   {ts.Add(-time.Minute).Format(time.RFC3339Nano)},
+			"to":     {ts.Add(time.Minute).Format(time.RFC3339Nano)},
+		}.Encode(),
+	})
+	if resp.Status != http.StatusOK {
+		t.Fatalf("status %d: %s", resp.Status, resp.Body)
+	}
+	if body := string(resp.Body); !strings.Contains(body, "10.0.0.8") {
+		t.Errorf("window edges are not the snapshot's:\n%s", body)
+	}
+
+	resp = instance.tableResource(context.Background(), &backend.CallResourceRequest{
+		URL: "table?" + url.Values{"snapshot": {ts.Format(time.RFC3339Nano)}}.Encode(),
+	})
+	if resp.Status != http.StatusOK {
+		t.Fatalf("status %d: %s", resp.Status, resp.Body)
+	}
+	var entries []tableEntry
+	if err := json.Unmarshal(resp.Body, &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 || entries[3].Pid != 30 {
+		t.Errorf("table is not the snapshot's: %v", entries)
+	}
+
+	resp = instance.nodesCSVResource(context.Background(), &backend.CallResourceRequest{
+		URL: "nodes.csv?to=yesterday",
+	})
+	if resp.Status != http.StatusBadRequest {
+		t.Errorf("status %d for an invalid time range, want %d", resp.Status, http.StatusBadRequest)
+	}
+}
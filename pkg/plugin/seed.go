@@ -0,0 +1,71 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"maps"
+	"net"
+	"path/filepath"
+	"slices"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/zosmac/gomon/process"
+)
+
+// seeded builds the graph centered on the query's seed file or host: the processes connected to it, their
+// ancestors and descendants, and their connections to the seed.
+func (query Query) seeded(tb process.Table) graph {
+	tr := tb.BuildTree()
+
+	hosts := map[Pid][]any{}
+	datas := map[Pid][]any{}
+	edges := map[[2]Pid][]any{}
+	include := process.Table{}
+
+	seed := query.model.SeedFile
+	if seed != "" {
+		for _, p := range tb {
+			for _, conn := range p.Connections {
+				if !isData(conn.Peer.Pid) || !seedMatch(seed, conn.Peer.Name) {
+					continue
+				}
+				include[p.Pid] = p
+				query.connect(tb, tr, conn, include, hosts, datas, edges)
+			}
+		}
+	} else {
+		seed = query.model.SeedHost
+		addrs := query.peerAddrs(tb, tr, seed)
+		for _, p := range tb {
+			for _, conn := range p.Connections {
+				if conn.Peer.Pid >= 0 || nodeArc(conn) != hostArc {
+					continue
+				}
+				if addr, _, _ := net.SplitHostPort(conn.Peer.Name); addrs[addr] == nil {
+					continue
+				}
+				include[p.Pid] = p
+				query.connect(tb, tr, conn, include, hosts, datas, edges)
+			}
+		}
+	}
+	if len(include) == 0 {
+		query.notices.add(data.NoticeSeverityInfo, "no processes connect to %s", seed)
+	}
+
+	// add the families of the connected processes, but not of the ancestors added with them
+	for _, pid := range slices.Collect(maps.Keys(include)) {
+		for _, pid := range tr.Family(pid).All() {
+			include[pid] = tb[pid]
+		}
+	}
+	return query.assemble(tb, include, hosts, datas, edges)
+}
+
+// seedMatch reports whether a file's path is the seed file, or matches it as a glob.
+func seedMatch(seed, name string) bool {
+	if ok, _ := filepath.Match(seed, name); ok {
+		return true
+	}
+	return name == seed
+}
@@ -0,0 +1,38 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"math"
+	"testing"
+
+	"github.com/zosmac/gomon/process"
+)
+
+func TestSeededFile(t *testing.T) {
+	const file = Pid(math.MaxInt32 + 5)
+	tb := process.Table{
+		1:  testProcess(1, 0, "init"),
+		20: testProcess(20, 1, "writer", testConnection("REG", 20, "", file, "/var/log/app.log")),
+		30: testProcess(30, 1, "idle"),
+	}
+	g := testQuery(queryModel{SeedFile: "/var/log/*.log"}, dataSourceSettings{}).seeded(tb)
+
+	nodes := map[Pid]bool{}
+	for _, n := range g.nodes {
+		nodes[nodeId(Pid(n[0].(int64)))] = true
+	}
+	if !nodes[20] || !nodes[file] {
+		t.Errorf("seed file and its writer are not nodes: %v", nodes)
+	}
+	if nodes[30] {
+		t.Error("process not connected to the seed is a node")
+	}
+	found := false
+	for _, e := range g.edges {
+		found = found || pidOf(Pid(e[1].(int64))) == 20 && Pid(e[2].(int64)) == file
+	}
+	if !found {
+		t.Errorf("edge from the writer to the seed file is missing: %v", g.edges)
+	}
+}
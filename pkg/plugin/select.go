@@ -0,0 +1,203 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zosmac/gocore"
+	"github.com/zosmac/gomon/process"
+)
+
+var (
+	// prevCPU records the cpu time of each process of the prior live table, guarded by graphLock.
+	prevCPU map[Pid]time.Duration
+)
+
+// cpuTimes records the cpu time of each process of a table.
+func cpuTimes(tb process.Table) map[Pid]time.Duration {
+	cpu := make(map[Pid]time.Duration, len(tb))
+	for pid, p := range tb {
+		cpu[pid] = p.Total
+	}
+	return cpu
+}
+
+// selected builds the graph of a process table, whether the live system's or not, selecting its processes and
+// connections as gomon's Nodegraph does. For a query pid, these are the process' extended family and the processes
+// connected to it. Otherwise, they are the non-daemon, remote host connected, and cpu consuming processes, where a
+// process without a sample in the query's prior cpu times counts as consuming cpu.
+func (query Query) selected(tb process.Table) graph {
+	tr := tb.BuildTree()
+
+	hosts := map[Pid][]any{}
+	datas := map[Pid][]any{}
+	edges := map[[2]Pid][]any{}
+	include := process.Table{}
+
+	queryPid := query.model.Pid
+	if queryPid != 0 && tb[queryPid] == nil {
+		queryPid = 0 // set to default
+	}
+
+	gocore.Error("Nodegraph", nil, map[string]string{
+		"pid": queryPid.String(),
+	}).Info()
+
+	pt := process.Table{}
+	if queryPid > 0 { // build this process' "extended family"
+		for _, pid := range tr.Family(queryPid).All() {
+			pt[pid] = tb[pid]
+		}
+		for _, p := range tb {
+			for _, conn := range p.Connections {
+				if conn.Peer.Pid == queryPid {
+					for _, pid := range tr.Ancestors(conn.Self.Pid) {
+						pt[pid] = tb[pid]
+					}
+					pt[conn.Self.Pid] = tb[conn.Self.Pid]
+				}
+			}
+		}
+	} else { // only report non-daemon, remote host connected, and cpu consuming processes
+		for pid, p := range tb {
+			if pcpu, ok := query.prevCPU[pid]; !ok || pcpu < p.Total {
+				pt[pid] = p
+			}
+			if p.Ppid > 1 {
+				for _, pid := range tr.Family(pid).All() {
+					pt[pid] = tb[pid]
+				}
+			}
+			for _, conn := range p.Connections {
+				if conn.Peer.Pid < 0 {
+					pt[conn.Self.Pid] = tb[conn.Self.Pid]
+				}
+			}
+		}
+	}
+
+	for pid, p := range pt {
+		include[pid] = p
+		for _, pid := range tr.Ancestors(pid) {
+			include[pid] = tb[pid] // add ancestor for BuildTree
+		}
+		for _, conn := range p.Connections {
+			if conn.Self.Pid == 0 || conn.Peer.Pid == 0 || // ignore kernel process
+				conn.Self.Pid == 1 || conn.Peer.Pid == 1 || // ignore init process
+				conn.Self.Pid == conn.Peer.Pid || // ignore inter-process connections
+				queryPid == 0 && isData(conn.Peer.Pid) || // ignore data connections for the "all process" query
+				(queryPid > 0 && queryPid != conn.Self.Pid && // ignore hosts and datas of connected processes
+					(conn.Peer.Pid < 0 || isData(conn.Peer.Pid))) {
+				continue
+			}
+			query.connect(tb, tr, conn, include, hosts, datas, edges)
+		}
+	}
+
+	return query.assemble(tb, include, hosts, datas, edges)
+}
+
+// connect adds a connection's peer node and its edge to the graph, labeling the edge with the connection.
+// A peer process and its ancestors are included in the graph.
+func (query Query) connect(
+	tb process.Table,
+	tr process.Tree,
+	conn process.Connection,
+	include process.Table,
+	hosts, datas map[Pid][]any,
+	edges map[[2]Pid][]any,
+) {
+	switch {
+	case conn.Peer.Pid < 0: // peer is remote host or listener
+		if _, ok := hosts[conn.Peer.Pid]; !ok {
+			hosts[conn.Peer.Pid] = query.HostNode(conn)
+		}
+		id := [2]Pid{conn.Peer.Pid, conn.Self.Pid} // host to the left
+		if _, ok := edges[id]; !ok {
+			edges[id] = query.HostEdge(tb, conn)
+		}
+		edges[id] = append(edges[id], fmt.Sprintf(
+			"%s:%s"+query.Arrow()+"%s[%d]",
+			conn.Type,
+			conn.Peer.Name,
+			conn.Self.Name,
+			conn.Self.Pid,
+		))
+	case isData(conn.Peer.Pid): // peer is data
+		if _, ok := datas[conn.Peer.Pid]; !ok {
+			datas[conn.Peer.Pid] = query.DataNode(conn)
+		}
+		id := [2]Pid{conn.Self.Pid, conn.Peer.Pid}
+		if _, ok := edges[id]; !ok {
+			edges[id] = query.DataEdge(tb, conn)
+		}
+		edges[id] = append(edges[id], fmt.Sprintf(
+			"%s"+query.Arrow()+"%s",
+			tb[conn.Self.Pid].Shortname(),
+			conn.Type+":"+conn.Peer.Name,
+		))
+	case tb[conn.Peer.Pid] == nil: // peer exited, its node is closed by BuildGraph
+		id := [2]Pid{conn.Self.Pid, conn.Peer.Pid}
+		if _, ok := edges[id]; !ok {
+			edges[id] = query.ProcEdge(tb, id[0], id[1])
+		}
+	default: // peer is process
+		include[conn.Peer.Pid] = tb[conn.Peer.Pid]
+		for _, pid := range tr.Ancestors(conn.Peer.Pid) {
+			include[pid] = tb[pid] // add ancestor for BuildTree
+		}
+
+		// show edge for inter-process connections only once
+		self, peer := conn.Self.Name, conn.Peer.Name
+		selfPid, peerPid := conn.Self.Pid, conn.Peer.Pid
+		if len(tr.Ancestors(selfPid)) > len(tr.Ancestors(peerPid)) ||
+			len(tr.Ancestors(selfPid)) == len(tr.Ancestors(peerPid)) && selfPid > peerPid {
+			selfPid, peerPid = peerPid, selfPid
+			self, peer = peer, self
+		}
+		id := [2]Pid{selfPid, peerPid}
+		if _, ok := edges[id]; !ok {
+			edges[id] = query.ProcEdge(tb, id[0], id[1])
+		}
+		edges[id] = append(edges[id], fmt.Sprintf(
+			"%s:%s[%d]"+query.Arrow()+"%s[%d]",
+			conn.Type,
+			self,
+			selfPid,
+			peer,
+			peerPid,
+		))
+	}
+}
+
+// assemble connects the included processes to their parents and builds the graph.
+func (query Query) assemble(tb, include process.Table, hosts, datas map[Pid][]any, edges map[[2]Pid][]any) graph {
+	itr := include.BuildTree()
+
+	// connect the parents to their children
+	var parents []Pid
+	for depth, pid := range itr.All() {
+		parents = append(parents[:depth], pid)
+		if depth == 0 {
+			continue
+		}
+		id := [2]Pid{parents[depth-1], pid}
+		if _, ok := edges[id]; !ok {
+			edges[id] = query.ProcEdge(tb, id[0], id[1])
+		}
+		edges[id] = append(edges[id], fmt.Sprintf(
+			"parent:%s"+query.Arrow()+"%s",
+			tb[id[0]].Shortname(),
+			tb[id[1]].Shortname(),
+		))
+	}
+
+	prcss := map[int]map[Pid][]any{}
+	for i := range itr.DepthTree() - 1 {
+		prcss[i] = map[Pid][]any{}
+	}
+
+	return query.BuildGraph(tb, itr, hosts, prcss, datas, edges)
+}
@@ -0,0 +1,78 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/zosmac/gomon/process"
+)
+
+// selectTable creates a table whose captured process is this test's own pid, so that reading its state from
+// /proc would report values.
+func selectTable() (process.Table, Pid) {
+	self := Pid(os.Getpid())
+	captured := testProcess(self, 1, "captured",
+		testConnection("TCP", self, "127.0.0.1:8080", 30, "127.0.0.1:51234"),
+	)
+	captured.Total = time.Second
+	client := testProcess(30, 1, "client",
+		testConnection("TCP", 30, "127.0.0.1:51234", self, "127.0.0.1:8080"),
+	)
+	client.Total = time.Second
+	return process.Table{1: testProcess(1, 0, "init"), self: captured, 30: client}, self
+}
+
+func TestSelectedReplay(t *testing.T) {
+	tb, self := selectTable()
+	g := testQuery(queryModel{}, dataSourceSettings{}).selected(tb)
+
+	var node []any
+	for _, n := range g.nodes {
+		if isProcess(Pid(n[0].(int64))) && pidOf(Pid(n[0].(int64))) == self {
+			node = n
+		}
+	}
+	if node == nil {
+		t.Fatalf("captured process %d is not selected: %v", self, g.nodes)
+	}
+	if policy := node[detailIndex("policy")]; policy != "" {
+		t.Errorf("replayed process reports the scheduling policy %q of the live pid", policy)
+	}
+	if ns := node[detailIndex("pidNamespace")]; ns != "" {
+		t.Errorf("replayed process reports the pid namespace %q of the live pid", ns)
+	}
+
+	connected := false
+	for _, e := range g.edges {
+		ids := [2]Pid{pidOf(Pid(e[1].(int64))), pidOf(Pid(e[2].(int64)))}
+		connected = connected || ids == [2]Pid{self, 30} || ids == [2]Pid{30, self}
+	}
+	if !connected {
+		t.Errorf("connection of %d and 30 is not an edge: %v", self, g.edges)
+	}
+}
+
+func TestSelectedPrevCPU(t *testing.T) {
+	tb, _ := selectTable()
+	tb[30].Connections = nil
+	tb[Pid(os.Getpid())].Connections = nil
+
+	query := testQuery(queryModel{}, dataSourceSettings{})
+	query.prevCPU = cpuTimes(tb)
+	tb[30].Total += time.Second
+	g := query.selected(tb)
+
+	ids := map[Pid]bool{}
+	for _, n := range g.nodes {
+		ids[pidOf(Pid(n[0].(int64)))] = true
+	}
+	if !ids[30] {
+		t.Error("process consuming cpu since the prior table is not selected")
+	}
+	if ids[Pid(os.Getpid())] {
+		t.Error("idle daemon process without remote connections is selected")
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"os"
+
+	"github.com/zosmac/gomon/process"
+)
+
+// hideSelf reports whether the plugin's own processes are excluded from the graph, which they are unless disabled.
+func (settings dataSourceSettings) hideSelf() bool {
+	return settings.HideSelf == nil || *settings.HideSelf
+}
+
+// ownProcesses identifies the plugin's process and its descendants, e.g. the lsof commands of the collector.
+// Another process' lsof is not a descendant of the plugin, so it remains in the graph.
+func ownProcesses(tb process.Table) map[Pid]struct{} {
+	self := Pid(os.Getpid())
+	own := map[Pid]struct{}{}
+	if tb[self] == nil {
+		return own
+	}
+	for pid, p := range tb {
+		for ; p != nil; p = tb[p.Ppid] {
+			if p.Pid == self {
+				own[pid] = struct{}{}
+				break
+			}
+			if p.Ppid <= 1 || p.Ppid == p.Pid {
+				break
+			}
+		}
+	}
+	return own
+}
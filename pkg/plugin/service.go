@@ -0,0 +1,57 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+var (
+	// services names the services of well known ports.
+	services = map[string]string{
+		"21":    "ftp",
+		"22":    "ssh",
+		"23":    "telnet",
+		"25":    "smtp",
+		"53":    "dns",
+		"80":    "http",
+		"110":   "pop3",
+		"123":   "ntp",
+		"143":   "imap",
+		"389":   "ldap",
+		"443":   "https",
+		"445":   "smb",
+		"465":   "smtps",
+		"587":   "submission",
+		"631":   "ipp",
+		"636":   "ldaps",
+		"993":   "imaps",
+		"995":   "pop3s",
+		"1433":  "mssql",
+		"1521":  "oracle",
+		"2049":  "nfs",
+		"2379":  "etcd",
+		"3000":  "grafana",
+		"3306":  "mysql",
+		"5353":  "mdns",
+		"5432":  "postgres",
+		"5672":  "amqp",
+		"6379":  "redis",
+		"6443":  "kubernetes",
+		"8080":  "http-alt",
+		"8443":  "https-alt",
+		"9090":  "prometheus",
+		"9092":  "kafka",
+		"9200":  "elasticsearch",
+		"11211": "memcached",
+		"27017": "mongodb",
+	}
+)
+
+// service names the service of a port, preferring the datasource's configured services to the well known ports.
+// An unknown port is reported as is.
+func (query Query) service(port string) string {
+	if name, ok := query.settings.Services[port]; ok {
+		return name
+	}
+	if name, ok := services[port]; ok {
+		return name
+	}
+	return port
+}
@@ -0,0 +1,54 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zosmac/gomon/process"
+)
+
+// isSharedMemory reports whether a data connection is to a POSIX shared memory segment or semaphore.
+// Linux maps POSIX shared memory as files in /dev/shm.
+func isSharedMemory(conn process.Connection) bool {
+	return conn.Type == "PSXSHM" || conn.Type == "PSXSEM" ||
+		conn.Type == "REG" && strings.HasPrefix(conn.Peer.Name, "/dev/shm/")
+}
+
+// sharedMemory adds the POSIX shared memory and semaphores of the graph's processes as data nodes, including those
+// that gomon omits from the all processes graph. The processes sharing a segment or semaphore link to one node.
+func (query Query) sharedMemory(
+	tb process.Table,
+	itr process.Tree,
+	folded map[Pid]Pid,
+	dropped map[Pid]struct{},
+	datas map[Pid][]any,
+	edges map[[2]Pid][]any,
+) {
+	for _, pid := range itr.All() {
+		if _, ok := folded[pid]; ok {
+			continue
+		}
+		if _, ok := dropped[pid]; ok || tb[pid] == nil {
+			continue
+		}
+		for _, conn := range tb[pid].Connections {
+			if !isSharedMemory(conn) {
+				continue
+			}
+			if _, ok := datas[conn.Peer.Pid]; !ok {
+				datas[conn.Peer.Pid] = query.DataNode(conn)
+			}
+			id := [2]Pid{pid, conn.Peer.Pid}
+			if _, ok := edges[id]; ok {
+				continue // gomon reported the connection
+			}
+			edges[id] = append(query.DataEdge(tb, conn), fmt.Sprintf(
+				"%s"+query.Arrow()+"%s",
+				tb[pid].Shortname(),
+				conn.Type+":"+conn.Peer.Name,
+			))
+		}
+	}
+}
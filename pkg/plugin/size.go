@@ -0,0 +1,50 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"github.com/zosmac/gomon/process"
+)
+
+const (
+	// defaultSize is the size of host, data, and thread nodes, and of all nodes if the size metric is unavailable.
+	defaultSize = 0.5
+)
+
+var (
+	// sizeMetrics measures each size by option of a process.
+	sizeMetrics = map[string]func(*process.Process) float64{
+		"connections": func(p *process.Process) float64 { return float64(len(p.Connections)) },
+		"cpu":         func(p *process.Process) float64 { return p.Total.Seconds() },
+		"memory":      func(p *process.Process) float64 { return float64(p.Resident) },
+		"fds":         func(p *process.Process) float64 { return float64(openFiles(p.Pid)) },
+	}
+)
+
+// size sets the size detail of the graph's process nodes to the query's size by metric,
+// normalized to the range 0 to 1 across the graph.
+func (query Query) size(tb process.Table, g *graph) {
+	metric, ok := sizeMetrics[query.model.SizeBy]
+	if !ok || query.model.SizeBy == "fds" && !query.live { // a replayed table's pids are not this system's
+		return
+	}
+
+	values := map[int64]float64{}
+	peak := 0.0
+	for _, n := range g.nodes {
+		id := n[0].(int64)
+		if p := tb[Pid(id)]; isProcess(Pid(id)) && p != nil && p.Pid == Pid(id) {
+			values[id] = metric(p)
+			peak = max(peak, values[id])
+		}
+	}
+	if peak <= 0 { // metric unavailable on this platform
+		return
+	}
+
+	for _, n := range g.nodes {
+		if value, ok := values[n[0].(int64)]; ok {
+			n[sizeDetail] = value / peak
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zosmac/gocore"
+	"github.com/zosmac/gomon/process"
+)
+
+type (
+	// snapshots retains a bounded history of the process table, from which the graph of any query may be built.
+	snapshots struct {
+		sync.Mutex
+		interval time.Duration
+		tables   []snapshot            // ring buffer of the retained tables
+		next     int                   // index of the ring buffer slot for the next table
+		prevCPU  map[Pid]time.Duration // cpu times of the processes of the latest table
+	}
+
+	// snapshot is a retained process table with its processes' connections.
+	snapshot struct {
+		timestamp time.Time
+		tb        process.Table
+		prevCPU   map[Pid]time.Duration // cpu times of the processes of the prior snapshot
+	}
+)
+
+const (
+	// minSnapshotInterval limits how often snapshots are recorded.
+	minSnapshotInterval = 10 * time.Second
+)
+
+// newSnapshots allocates the ring buffer for a number of retained snapshots.
+func newSnapshots(retention int, interval time.Duration) *snapshots {
+	return &snapshots{
+		interval: max(interval, minSnapshotInterval),
+		tables:   make([]snapshot, 0, retention),
+	}
+}
+
+// record periodically adds a snapshot of the process table until the context is cancelled.
+func (s *snapshots) record(ctx context.Context, settings dataSourceSettings) {
+	gocore.Error("snapshots", nil, map[string]string{
+		"retention": strconv.Itoa(cap(s.tables)),
+		"interval":  s.interval.String(),
+	}).Info()
+
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			s.add(collect(ctx, settings))
+		}
+	}
+}
+
+// collect collects the process table of a snapshot. The live table is built under graphLock, but its sockets are
+// not sampled and the cpu times of the live queries' processes are not recorded, so that each query still reports
+// the rates and cpu consumption since the prior query.
+func collect(ctx context.Context, settings dataSourceSettings) process.Table {
+	if settings.live() {
+		return lockedTable(true)
+	}
+	return newQuery(ctx, queryModel{}, settings, time.Now()).table(true)
+}
+
+// add inserts a table into the ring buffer, evicting the oldest when full.
+func (s *snapshots) add(tb process.Table) {
+	s.Lock()
+	defer s.Unlock()
+	snap := snapshot{timestamp: time.Now(), tb: tb, prevCPU: s.prevCPU}
+	s.prevCPU = cpuTimes(tb)
+	if len(s.tables) < cap(s.tables) {
+		s.tables = append(s.tables, snap)
+	} else {
+		s.tables[s.next] = snap
+	}
+	s.next = (s.next + 1) % cap(s.tables)
+}
+
+// closest returns the retained snapshot recorded nearest to a time, if one was recorded within the snapshot interval.
+func (s *snapshots) closest(t time.Time) (snapshot, bool) {
+	s.Lock()
+	defer s.Unlock()
+	var snap snapshot
+	var ok bool
+	delta := s.interval
+	for _, sn := range s.tables {
+		if d := sn.timestamp.Sub(t).Abs(); d <= delta {
+			snap, ok, delta = sn, true, d
+		}
+	}
+	return snap, ok
+}
+
+// timestamps returns the recording times of the retained snapshots, oldest first.
+func (s *snapshots) timestamps() []time.Time {
+	s.Lock()
+	defer s.Unlock()
+	ts := make([]time.Time, len(s.tables))
+	for i, snap := range s.tables {
+		ts[i] = snap.timestamp
+	}
+	slices.SortFunc(ts, func(a, b time.Time) int {
+		return cmp.Compare(a.UnixNano(), b.UnixNano())
+	})
+	return ts
+}
+
+// at returns the retained snapshot recorded at a time, if it has not been evicted.
+func (s *snapshots) at(t time.Time) (snapshot, bool) {
+	s.Lock()
+	defer s.Unlock()
+	for _, snap := range s.tables {
+		if snap.timestamp.Equal(t) {
+			return snap, true
+		}
+	}
+	return snapshot{}, false
+}
+
+// build builds the graph of the snapshot's table with a query's options, as of the snapshot's time.
+// The table is not the live system's, so no state of its processes is read.
+func (snap snapshot) build(ctx context.Context, model queryModel, settings dataSourceSettings) graph {
+	query := newQuery(ctx, model, settings, time.Now())
+	query.prevCPU = snap.prevCPU
+	query.collected = snap.timestamp
+	var g graph
+	if model.TreeOnly {
+		g = query.tree(snap.tb)
+	} else {
+		g = query.build(snap.tb)
+	}
+	g.timestamp = snap.timestamp
+	return g
+}
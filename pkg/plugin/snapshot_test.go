@@ -0,0 +1,67 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"context"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/zosmac/gomon/process"
+)
+
+// snapshotTable creates a table of two unrelated process families connected to remote hosts.
+func snapshotTable() process.Table {
+	return process.Table{
+		1:  testProcess(1, 0, "init"),
+		20: testProcess(20, 1, "server", testConnection("TCP", 20, "10.0.0.2:443", -3, "10.0.0.9:51234")),
+		21: testProcess(21, 20, "worker"),
+		30: testProcess(30, 1, "client", testConnection("TCP", 30, "10.0.0.2:51000", -4, "10.0.0.8:443")),
+	}
+}
+
+func TestSnapshotQueryOptions(t *testing.T) {
+	live := map[Pid]time.Duration{99: time.Second}
+	defer func(p map[Pid]time.Duration) { prevCPU = p }(prevCPU)
+	prevCPU = maps.Clone(live)
+
+	s := newSnapshots(2, minSnapshotInterval)
+	s.add(snapshotTable())
+	snap, ok := s.closest(time.Now())
+	if !ok {
+		t.Fatal("snapshot not retained")
+	}
+
+	pids := func(g graph) map[Pid]bool {
+		ids := map[Pid]bool{}
+		for _, n := range g.nodes {
+			if id := Pid(n[0].(int64)); isProcess(id) {
+				ids[pidOf(id)] = true
+			}
+		}
+		return ids
+	}
+
+	all := pids(snap.build(context.Background(), queryModel{}, dataSourceSettings{}))
+	if !all[20] || !all[30] {
+		t.Errorf("all processes graph of the snapshot lacks a process: %v", all)
+	}
+	family := pids(snap.build(context.Background(), queryModel{Pid: 20}, dataSourceSettings{}))
+	if !family[20] || !family[21] || family[30] {
+		t.Errorf("pid 20 graph of the snapshot is not its family: %v", family)
+	}
+	tree := snap.build(context.Background(), queryModel{TreeOnly: true}, dataSourceSettings{})
+	if !tree.timestamp.Equal(snap.timestamp) {
+		t.Errorf("snapshot graph timestamp %v, want %v", tree.timestamp, snap.timestamp)
+	}
+
+	u := s.union(context.Background(), queryModel{Pid: 30}, dataSourceSettings{}, snap.timestamp, snap.timestamp)
+	if window := pids(u); !window[30] || window[20] {
+		t.Errorf("pid 30 window of the snapshots is not its family: %v", window)
+	}
+
+	if !maps.Equal(prevCPU, live) {
+		t.Error("snapshots disturbed the cpu times of the live queries")
+	}
+}
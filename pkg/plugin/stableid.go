@@ -0,0 +1,33 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/zosmac/gomon/process"
+)
+
+// stableIds qualifies the ids of process nodes with their start times, so that a recycled pid is reported as a
+// new node rather than as the same node changing identity between refreshes. The qualifier is the low bits of the
+// start time in seconds, set in the qualifier bits of the id layout, so the id remains a process id below 2^53.
+// Thread nodes and processes without a start time, e.g. of a capture, keep their pids as ids.
+func (query Query) stableIds(tb process.Table, g *graph) {
+	stable := func(id int64) int64 {
+		if !isProcess(Pid(id)) || id == 0 {
+			return id
+		}
+		if p := tb[Pid(id)]; p != nil && !p.Id.Starttime.IsZero() {
+			return p.Id.Starttime.Unix()<<32&qualifierMask | id
+		}
+		return id
+	}
+
+	for _, n := range g.nodes {
+		n[0] = stable(n[0].(int64))
+	}
+	for _, e := range g.edges {
+		e[1], e[2] = stable(e[1].(int64)), stable(e[2].(int64))
+		e[0] = fmt.Sprintf("%d -> %d", e[1], e[2])
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zosmac/gomon/process"
+)
+
+func TestStableIds(t *testing.T) {
+	query := testQuery(queryModel{}, dataSourceSettings{})
+	server := testProcess(20, 1, "server")
+	server.Id.Starttime = time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+	recycled := testProcess(20, 1, "server")
+	recycled.Id.Starttime = server.Id.Starttime.Add(time.Minute)
+	capture := testProcess(30, 1, "captured")
+
+	build := func(p *process.Process) graph {
+		tb := process.Table{p.Pid: p}
+		g := graph{
+			nodes: [][]any{
+				{int64(-5)},
+				{int64(p.Pid)},
+				{int64(selfBase | p.Pid)},
+				{int64(closedBase | 40)},
+			},
+			edges: [][]any{
+				{"", int64(-5), int64(p.Pid)},
+				{"", int64(p.Pid), int64(selfBase | p.Pid)},
+			},
+		}
+		query.stableIds(tb, &g)
+		return g
+	}
+
+	g := build(server)
+	id := Pid(g.nodes[1][0].(int64))
+	if id == 20 {
+		t.Fatal("process id is not qualified by its start time")
+	}
+	if id >= 1<<53 {
+		t.Errorf("id %#x exceeds the frontend's integer precision", id)
+	}
+	if !isProcess(id) || isData(id) || isClosed(id) {
+		t.Errorf("id %#x is not classified as a process", id)
+	}
+	if pidOf(id) != 20 {
+		t.Errorf("pidOf(%#x) = %d, want 20", id, pidOf(id))
+	}
+	if g.edges[0][2] != int64(id) || g.edges[1][1] != int64(id) || g.edges[0][0] != "-5 -> "+id.String() {
+		t.Errorf("edges do not reference the stable id: %v", g.edges)
+	}
+	for i, want := range []int64{-5, selfBase | 20, closedBase | 40} {
+		if n := g.nodes[[]int{0, 2, 3}[i]]; n[0] != want {
+			t.Errorf("pseudo-node id %#x changed to %#x", want, n[0])
+		}
+	}
+
+	if other := Pid(build(recycled).nodes[1][0].(int64)); other == id {
+		t.Errorf("recycled pid has the same id %#x", id)
+	}
+	if g := build(capture); g.nodes[1][0] != int64(30) {
+		t.Errorf("process without a start time has id %#x", g.nodes[1][0])
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	// commWidth is the width that the kernel truncates a process' command name to, TASK_COMM_LEN less its NUL.
+	commWidth = 15
+)
+
+var (
+	// policies names the scheduling policies of sched_setscheduler(2).
+	policies = map[int]string{
+		0: "other",
+		1: "fifo",
+		2: "rr",
+		3: "batch",
+		5: "idle",
+		6: "deadline",
+	}
+)
+
+// statFields reads the fields of the process' stat that follow the command name, so field n of proc(5) is index n-3.
+func statFields(pid Pid) []string {
+	buf, err := os.ReadFile(filepath.Join("/proc", pid.String(), "stat"))
+	if err != nil {
+		return nil
+	}
+	// the command name may contain spaces, so fields are counted from its closing parenthesis
+	i := bytes.LastIndexByte(buf, ')')
+	if i < 0 {
+		return nil
+	}
+	fields := []string{}
+	for _, field := range bytes.Fields(buf[i+1:]) {
+		fields = append(fields, string(field))
+	}
+	return fields
+}
+
+// startTime reads the process' start time, in clock ticks since boot, from its stat.
+func startTime(pid Pid) int64 {
+	fields := statFields(pid)
+	if len(fields) < 20 {
+		return 0
+	}
+	start, _ := strconv.ParseInt(fields[19], 10, 64) // field 22 of stat
+	return start
+}
+
+// scheduling reads the process' priority, nice value, and scheduling policy from its stat.
+func scheduling(pid Pid) (priority, nice int64, policy string) {
+	fields := statFields(pid)
+	if len(fields) < 39 {
+		return 0, 0, ""
+	}
+	priority, _ = strconv.ParseInt(fields[15], 10, 64)  // field 18 of stat
+	nice, _ = strconv.ParseInt(fields[16], 10, 64)      // field 19 of stat
+	if p, err := strconv.Atoi(fields[38]); err == nil { // field 41 of stat
+		policy = policies[p]
+	}
+	return priority, nice, policy
+}
+
+// executable reads the path of the process' executable.
+func executable(pid Pid) string {
+	exe, err := os.Readlink(filepath.Join("/proc", pid.String(), "exe"))
+	if err != nil {
+		return ""
+	}
+	return exe
+}
+
+// openFiles counts the process' open file descriptors.
+func openFiles(pid Pid) int {
+	fds, err := os.ReadDir(filepath.Join("/proc", pid.String(), "fd"))
+	if err != nil {
+		return 0
+	}
+	return len(fds)
+}
@@ -0,0 +1,30 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+//go:build !linux
+
+package plugin
+
+const (
+	// commWidth is the width that the kernel truncates a process' command name to, MAXCOMLEN on darwin.
+	commWidth = 16
+)
+
+// startTime is only determined for Linux processes.
+func startTime(Pid) int64 {
+	return 0
+}
+
+// scheduling is only determined for Linux processes.
+func scheduling(Pid) (priority, nice int64, policy string) {
+	return 0, 0, ""
+}
+
+// executable is only determined for Linux processes.
+func executable(Pid) string {
+	return ""
+}
+
+// openFiles is only determined for Linux processes.
+func openFiles(Pid) int {
+	return 0
+}
@@ -4,6 +4,7 @@ package plugin
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"time"
@@ -13,6 +14,46 @@ import (
 	"github.com/zosmac/gocore"
 )
 
+type (
+	// streamHints are the dashboard's refresh hints that a stream's subscription may send in its data.
+	streamHints struct {
+		IntervalMs    int64 `json:"intervalMs"`    // the query's interval
+		MaxDataPoints int64 `json:"maxDataPoints"` // the query's maximum data points over its time range
+		RangeMs       int64 `json:"rangeMs"`       // the query's time range
+	}
+)
+
+const (
+	// defaultStreamInterval is the stream's publish cadence absent the dashboard's hints.
+	defaultStreamInterval = 10 * time.Second
+	// defaultStreamMinInterval and defaultStreamMaxInterval clamp the publish cadence absent the settings'.
+	defaultStreamMinInterval = 5 * time.Second
+	defaultStreamMaxInterval = 5 * time.Minute
+)
+
+// streamInterval derives the stream's publish cadence from the dashboard's hints, clamped to the settings' limits.
+// The query's interval is preferred; otherwise its time range is divided by its maximum data points.
+func (settings dataSourceSettings) streamInterval(raw json.RawMessage) time.Duration {
+	interval := defaultStreamInterval
+	var hints streamHints
+	if len(raw) > 0 && json.Unmarshal(raw, &hints) == nil {
+		if hints.IntervalMs > 0 {
+			interval = time.Duration(hints.IntervalMs) * time.Millisecond
+		} else if hints.RangeMs > 0 && hints.MaxDataPoints > 0 {
+			interval = time.Duration(hints.RangeMs/hints.MaxDataPoints) * time.Millisecond
+		}
+	}
+
+	lo, hi := defaultStreamMinInterval, defaultStreamMaxInterval
+	if settings.StreamMinInterval > 0 {
+		lo = time.Duration(settings.StreamMinInterval) * time.Second
+	}
+	if settings.StreamMaxInterval > 0 {
+		hi = time.Duration(settings.StreamMaxInterval) * time.Second
+	}
+	return min(max(interval, lo), max(lo, hi))
+}
+
 // TODO: flesh this out so it actually works. So far, this is just placeholder code.
 
 // RunStream initiates data source's stream to channel.
@@ -23,6 +64,14 @@ func (dsi *Instance) RunStream(ctx context.Context, req *backend.RunStreamReques
 		"request":  fmt.Sprint(*req),
 	}).Info()
 
+	interval := dsi.settings.streamInterval(req.Data)
+	gocore.Error("RunStream interval", nil, map[string]string{
+		"path":     req.Path,
+		"interval": interval.String(),
+	}).Info()
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
 	for {
 		select {
 		case <-ctx.Done():
@@ -30,7 +79,7 @@ func (dsi *Instance) RunStream(ctx context.Context, req *backend.RunStreamReques
 				"path": req.Path,
 			}).Err()
 			return nil
-		case <-time.After(time.Second * 10):
+		case <-t.C:
 			dsi.Stream.Messages += 1
 			gocore.Error("RunStream", nil, map[string]string{
 				"path":     req.Path,
@@ -43,7 +92,7 @@ func (dsi *Instance) RunStream(ctx context.Context, req *backend.RunStreamReques
 				req.PluginContext.DataSourceInstanceSettings.Name,
 			)
 
-			resp := Nodegraph(link, 0)
+			resp := Nodegraph(ctx, link, queryModel{Streaming: true}, dsi.settings)
 			for _, frame := range resp.Frames {
 				if err := sender.SendFrame(frame, data.IncludeAll); err != nil {
 					gocore.Error("SendFrame", nil, map[string]string{
@@ -0,0 +1,34 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// threads reads the ids and names of a process' threads, other than its main thread.
+func threads(pid Pid) map[Pid]string {
+	dir := filepath.Join("/proc", pid.String(), "task")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	tids := map[Pid]string{}
+	for _, entry := range entries {
+		tid, err := strconv.Atoi(entry.Name())
+		if err != nil || Pid(tid) == pid {
+			continue
+		}
+		comm, err := os.ReadFile(filepath.Join(dir, entry.Name(), "comm"))
+		if err != nil { // thread exited
+			continue
+		}
+		tids[Pid(tid)] = strings.TrimSpace(string(comm))
+	}
+
+	return tids
+}
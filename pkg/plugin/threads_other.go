@@ -0,0 +1,10 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+//go:build !linux
+
+package plugin
+
+// threads are only reported for Linux processes.
+func threads(Pid) map[Pid]string {
+	return nil
+}
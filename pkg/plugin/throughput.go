@@ -0,0 +1,144 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"net"
+	"time"
+
+	"github.com/zosmac/gomon/process"
+)
+
+type (
+	// socketKey identifies a socket by its local and peer addresses.
+	socketKey [2]string
+
+	// tcpInfo holds the sent and received byte counts, the smoothed round trip time, and the send and receive
+	// queue depths of a TCP socket.
+	tcpInfo struct {
+		bytes  [2]int64
+		rtt    float64  // milliseconds
+		queues [2]int64 // bytes not yet acknowledged by the peer, and not yet read by the process
+	}
+
+	// sample holds the tcp info of sockets at a point in time.
+	sample struct {
+		time    time.Time
+		sockets map[socketKey]tcpInfo
+	}
+
+	// rates holds the sent and received bytes per second of sockets.
+	rates map[socketKey][2]float64
+
+	// rtts holds the smoothed round trip times of established TCP sockets, in milliseconds.
+	rtts map[socketKey]float64
+
+	// queues holds the send and receive queue depths of established TCP sockets, in bytes.
+	queues map[socketKey][2]int64
+)
+
+var (
+	// lastSample is the prior sample of socket byte counts, guarded by graphLock.
+	lastSample sample
+)
+
+// sampleSockets samples the sockets' tcp info, deriving their rates since the prior sample, their round trip times,
+// and their queue depths. Sockets without counters, or not in the prior sample, have no rate.
+func sampleSockets() (rates, rtts, queues) {
+	curr := sample{time: time.Now(), sockets: sockets()}
+	prev := lastSample
+	lastSample = curr
+
+	r, t, q := rates{}, rtts{}, queues{}
+	for key, info := range curr.sockets {
+		if info.rtt > 0 {
+			t[key] = info.rtt
+		}
+		q[key] = info.queues
+	}
+	secs := curr.time.Sub(prev.time).Seconds()
+	if prev.sockets == nil || secs <= 0 {
+		return r, t, q
+	}
+	for key, info := range curr.sockets {
+		if last, ok := prev.sockets[key]; ok && info.bytes[0] >= last.bytes[0] && info.bytes[1] >= last.bytes[1] {
+			r[key] = [2]float64{
+				float64(info.bytes[0]-last.bytes[0]) / secs,
+				float64(info.bytes[1]-last.bytes[1]) / secs,
+			}
+		}
+	}
+	return r, t, q
+}
+
+// edgeSockets returns the keys of the sockets connecting the nodes of an edge, from the perspective of its process.
+func edgeSockets(tb process.Table, id [2]Pid) []socketKey {
+	self, peer := id[0], id[1]
+	if self < 0 { // host edges are drawn from host to process
+		self, peer = peer, self
+	}
+	if !isProcess(peer) && peer > 0 || tb[self] == nil {
+		return nil
+	}
+	var keys []socketKey
+	for _, conn := range tb[self].Connections {
+		if conn.Peer.Pid == peer {
+			keys = append(keys, socketKey{normalAddress(conn.Self.Name), normalAddress(conn.Peer.Name)})
+		}
+	}
+	return keys
+}
+
+// edge sums the rates of the sockets connecting the nodes of an edge.
+// The rates are nil if none of the edge's sockets report a rate.
+func (r rates) edge(tb process.Table, id [2]Pid) (sent, received *float64) {
+	for _, key := range edgeSockets(tb, id) {
+		if rate, ok := r[key]; ok {
+			if sent == nil {
+				sent, received = new(float64), new(float64)
+			}
+			*sent += rate[0]
+			*received += rate[1]
+		}
+	}
+	return sent, received
+}
+
+// edge reports the longest round trip time of the sockets connecting the nodes of an edge.
+// The round trip time is nil if none of the edge's sockets is an established TCP socket.
+func (t rtts) edge(tb process.Table, id [2]Pid) *float64 {
+	var rtt *float64
+	for _, key := range edgeSockets(tb, id) {
+		if ms, ok := t[key]; ok && (rtt == nil || *rtt < ms) {
+			rtt = &ms
+		}
+	}
+	return rtt
+}
+
+// edge sums the send and receive queue depths of the TCP sockets connecting the nodes of an edge.
+// The depths are nil if none of the edge's sockets is an established TCP socket.
+func (q queues) edge(tb process.Table, id [2]Pid) (send, recv *int64) {
+	for _, key := range edgeSockets(tb, id) {
+		if depths, ok := q[key]; ok {
+			if send == nil {
+				send, recv = new(int64), new(int64)
+			}
+			*send += depths[0]
+			*recv += depths[1]
+		}
+	}
+	return send, recv
+}
+
+// normalAddress formats a host:port address consistently for matching sockets reported by different sources.
+func normalAddress(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		host = ip.String() // unmaps IPv4-mapped IPv6 addresses
+	}
+	return net.JoinHostPort(host, port)
+}
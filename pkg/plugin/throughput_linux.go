@@ -0,0 +1,75 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// sockets reports the sent and received byte counts, round trip times, and queue depths of established TCP sockets
+// from ss(8), keyed by local and peer address.
+func sockets() map[socketKey]tcpInfo {
+	out, err := exec.Command("ss", "-tinH").Output()
+	if err != nil {
+		return nil
+	}
+	return parseSockets(out)
+}
+
+// parseSockets parses the output of ss -tinH: for each socket a line of its state, queue depths, and addresses,
+// followed by an indented line of its tcp_info. The tcp_info of a socket whose line is malformed is ignored.
+func parseSockets(out []byte) map[socketKey]tcpInfo {
+	infos := map[socketKey]tcpInfo{}
+	var key socketKey
+	var queues [2]int64
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' { // state recv-q send-q local peer
+			key = socketKey{}
+			if len(fields) >= 5 {
+				recvQ, err1 := strconv.ParseInt(fields[1], 10, 64)
+				sendQ, err2 := strconv.ParseInt(fields[2], 10, 64)
+				if err1 == nil && err2 == nil {
+					key = socketKey{normalAddress(fields[3]), normalAddress(fields[4])}
+					queues = [2]int64{sendQ, recvQ}
+				}
+			}
+			continue
+		}
+		if key == (socketKey{}) {
+			continue
+		}
+		var sent, acked, received int64
+		var rtt float64
+		for _, field := range fields { // tcp_info of the socket
+			name, value, _ := strings.Cut(field, ":")
+			switch name {
+			case "bytes_sent":
+				sent, _ = strconv.ParseInt(value, 10, 64)
+			case "bytes_acked":
+				acked, _ = strconv.ParseInt(value, 10, 64)
+			case "bytes_received":
+				received, _ = strconv.ParseInt(value, 10, 64)
+			case "rtt": // smoothed/variance
+				srtt, _, _ := strings.Cut(value, "/")
+				rtt, _ = strconv.ParseFloat(srtt, 64)
+			}
+		}
+		if sent == 0 { // older kernels only report acknowledged bytes
+			sent = acked
+		}
+		infos[key] = tcpInfo{bytes: [2]int64{sent, received}, rtt: rtt, queues: queues}
+		key = socketKey{}
+	}
+
+	return infos
+}
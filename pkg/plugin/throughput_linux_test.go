@@ -0,0 +1,43 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"testing"
+)
+
+func TestParseSockets(t *testing.T) {
+	out := []byte(`ESTAB 0 0 10.0.0.2:51000 10.0.0.8:443
+	 cubic wscale:7,7 rto:204 rtt:1.5/0.75 bytes_sent:1200 bytes_acked:1201 bytes_received:3400 segs_out:10
+ESTAB 12 34 [::1]:8080 [::1]:51234
+	 cubic rto:201 rtt:0.05/0.025 bytes_acked:500 bytes_received:700
+ESTAB 0 0 [::ffff:127.0.0.1]:6000 [::ffff:127.0.0.1]:51002
+	 cubic rto:200 bytes_sent:10 bytes_received:20
+ESTAB x 0 10.0.0.2:51001 10.0.0.9:443
+	 cubic rtt:9/1 bytes_sent:99 bytes_received:99
+garbage
+	 cubic rtt:8/1 bytes_sent:88 bytes_received:88
+	 cubic rtt:7/1 bytes_sent:77 bytes_received:77
+
+ESTAB 0 0 10.0.0.2:51003 10.0.0.7:22
+`)
+	want := map[socketKey]tcpInfo{
+		{"10.0.0.2:51000", "10.0.0.8:443"}:    {bytes: [2]int64{1200, 3400}, rtt: 1.5},
+		{"[::1]:8080", "[::1]:51234"}:         {bytes: [2]int64{500, 700}, rtt: 0.05, queues: [2]int64{34, 12}},
+		{"127.0.0.1:6000", "127.0.0.1:51002"}: {bytes: [2]int64{10, 20}}, // no rtt: field
+	}
+
+	infos := parseSockets(out)
+	if len(infos) != len(want) {
+		t.Errorf("parsed %d sockets, want %d: %v", len(infos), len(want), infos)
+	}
+	for key, info := range want {
+		if got, ok := infos[key]; !ok || got != info {
+			t.Errorf("socket %v is %+v, %t, want %+v", key, got, ok, info)
+		}
+	}
+
+	if infos := parseSockets(nil); len(infos) != 0 {
+		t.Errorf("parsed %d sockets from no output", len(infos))
+	}
+}
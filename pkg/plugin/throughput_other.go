@@ -0,0 +1,10 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+//go:build !linux
+
+package plugin
+
+// sockets' byte counts and round trip times are only determined for Linux.
+func sockets() map[socketKey]tcpInfo {
+	return nil
+}
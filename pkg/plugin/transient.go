@@ -0,0 +1,147 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/zosmac/gocore"
+	"github.com/zosmac/gomon/process"
+)
+
+const (
+	// transientRetention is how long an exited short-lived process remains in the overlay.
+	transientRetention = 5 * time.Minute
+)
+
+type (
+	// transient records a process observed from its exec to its exit by the kernel's process events.
+	transient struct {
+		pid        Pid
+		ppid       Pid
+		name       string
+		executable string
+		uid        int
+		exec       time.Time
+		exit       time.Time
+	}
+)
+
+var (
+	// transients records the processes observed since their exec, and the recently exited, by pid.
+	transients = struct {
+		sync.Mutex
+		running map[Pid]*transient
+		exited  []*transient
+	}{
+		running: map[Pid]*transient{},
+	}
+)
+
+// observeTransients records short-lived processes from the kernel's process exec and exit events until the
+// context is cancelled. Observing the events requires elevated privileges.
+func observeTransients(ctx context.Context) {
+	self := Pid(os.Getpid())
+	err := processEvents(ctx, func(pid Pid) {
+		t := readTransient(pid)
+		if t.ppid == self { // the collector's own commands, e.g. lsof
+			return
+		}
+		transients.Lock()
+		transients.running[pid] = t
+		transients.Unlock()
+	}, func(pid Pid) {
+		now := time.Now()
+		transients.Lock()
+		defer transients.Unlock()
+		if t, ok := transients.running[pid]; ok {
+			delete(transients.running, pid)
+			t.exit = now
+			transients.exited = append(transients.exited, t)
+		}
+		for len(transients.exited) > 0 && now.Sub(transients.exited[0].exit) > transientRetention {
+			transients.exited = transients.exited[1:]
+		}
+	})
+	if err != nil {
+		gocore.Error("process events", err).Err()
+	}
+}
+
+// recentTransients returns the processes that exited within the retention period, oldest first.
+func recentTransients() []transient {
+	transients.Lock()
+	defer transients.Unlock()
+	var ts []transient
+	for _, t := range transients.exited {
+		if time.Since(t.exit) <= transientRetention {
+			ts = append(ts, *t)
+		}
+	}
+	return ts
+}
+
+// process reconstructs the table entry of a transient process.
+func (t transient) process() *process.Process {
+	p := &process.Process{}
+	p.Pid = t.pid
+	p.Ppid = t.ppid
+	p.Id.Name = t.name
+	p.Id.Starttime = t.exec
+	p.Executable = t.executable
+	p.UID = t.uid
+	return p
+}
+
+// addTransients adds the recently exited short-lived processes missing from the table as process nodes, each a
+// child of its parent's node. A transient whose parent is not in the graph is omitted. The table is shared, e.g. by
+// snapshots, so the transients are added to a copy of it, which is returned for finishing the graph's nodes.
+func (query Query) addTransients(
+	tb process.Table,
+	prcss map[int]map[Pid][]any,
+	edges map[[2]Pid][]any,
+) process.Table {
+	shared := tb
+	depths := map[Pid]int{}
+	for depth, nodes := range prcss {
+		for pid := range nodes {
+			depths[pid] = depth
+		}
+	}
+	for _, t := range recentTransients() {
+		if tb[t.pid] != nil {
+			continue
+		}
+		depth, ok := depths[t.ppid]
+		if !ok {
+			continue
+		}
+		p := t.process()
+		if len(tb) == len(shared) { // the first transient
+			tb = maps.Clone(shared)
+		}
+		tb[t.pid] = p
+		if prcss[depth+1] == nil {
+			prcss[depth+1] = map[Pid][]any{}
+		}
+		node := query.ProcNode(p)
+		copy(node[4:4+arcs], arc(transientArc))
+		node[transientDetail] = fmt.Sprintf("exited %s after %s",
+			t.exit.Format(time.TimeOnly), t.exit.Sub(t.exec).Round(time.Millisecond))
+		prcss[depth+1][t.pid] = node
+		depths[t.pid] = depth + 1
+
+		id := [2]Pid{t.ppid, t.pid}
+		edges[id] = append(query.ProcEdge(tb, id[0], id[1]), fmt.Sprintf(
+			"parent:%s"+query.Arrow()+"%s",
+			tb[id[0]].Shortname(),
+			p.Shortname(),
+		))
+	}
+	return tb
+}
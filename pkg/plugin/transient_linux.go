@@ -0,0 +1,133 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/zosmac/gocore"
+)
+
+const (
+	// cnIdxProc and cnValProc route the netlink connector's messages of the process events, per linux/connector.h.
+	cnIdxProc = 1
+	cnValProc = 1
+
+	// procCnMcastListen subscribes to the process events, per linux/cn_proc.h.
+	procCnMcastListen = 1
+
+	// procEventExec and procEventExit are the kinds of the process events observed, per linux/cn_proc.h.
+	procEventExec = 0x00000002
+	procEventExit = 0x80000000
+
+	// cnMsgLen and procEventLen are the sizes of struct cn_msg and of the header of struct proc_event.
+	cnMsgLen     = 20
+	procEventLen = 16
+)
+
+// processEvents subscribes to the netlink process connector, reporting the exec and exit of each process until
+// the context is cancelled. Threads' events are ignored.
+func processEvents(ctx context.Context, exec, exit func(Pid)) error {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM, syscall.NETLINK_CONNECTOR)
+	if err != nil {
+		return gocore.Error("socket", err)
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: cnIdxProc,
+	}); err != nil {
+		syscall.Close(fd)
+		return gocore.Error("bind", err)
+	}
+
+	// nlmsghdr, cn_msg, and the multicast op
+	req := make([]byte, syscall.NLMSG_HDRLEN+cnMsgLen+4)
+	gocore.HostEndian.PutUint32(req[0:], uint32(len(req)))     // nlmsg_len
+	gocore.HostEndian.PutUint16(req[4:], syscall.NLMSG_DONE)   // nlmsg_type
+	gocore.HostEndian.PutUint32(req[12:], uint32(os.Getpid())) // nlmsg_pid
+	msg := req[syscall.NLMSG_HDRLEN:]
+	gocore.HostEndian.PutUint32(msg[0:], cnIdxProc)                // idx
+	gocore.HostEndian.PutUint32(msg[4:], cnValProc)                // val
+	gocore.HostEndian.PutUint16(msg[16:], 4)                       // len
+	gocore.HostEndian.PutUint32(msg[cnMsgLen:], procCnMcastListen) // op
+	if err := syscall.Sendto(fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		syscall.Close(fd)
+		return gocore.Error("netlink process connector", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		syscall.Close(fd) // interrupts the receive
+	}()
+
+	buf := make([]byte, 16384)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return gocore.Error("Recvfrom", err)
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			continue
+		}
+		for _, m := range msgs {
+			if m.Header.Type != syscall.NLMSG_DONE || len(m.Data) < cnMsgLen+procEventLen+8 {
+				continue
+			}
+			what := gocore.HostEndian.Uint32(m.Data[cnMsgLen:])
+			ev := m.Data[cnMsgLen+procEventLen:]
+			pid, tgid := gocore.HostEndian.Uint32(ev[0:]), gocore.HostEndian.Uint32(ev[4:])
+			if pid != tgid { // a thread
+				continue
+			}
+			switch what {
+			case procEventExec:
+				exec(Pid(pid))
+			case procEventExit:
+				exit(Pid(pid))
+			}
+		}
+	}
+}
+
+// readTransient reads the name, parent, executable, and user of a process as it execs.
+func readTransient(pid Pid) *transient {
+	t := &transient{
+		pid:        pid,
+		executable: executable(pid),
+		exec:       time.Now(),
+	}
+	if buf, err := os.ReadFile(filepath.Join("/proc", pid.String(), "comm")); err == nil {
+		t.name = strings.TrimSpace(string(buf))
+	}
+	if fields := statFields(pid); len(fields) > 1 {
+		ppid, _ := strconv.Atoi(fields[1]) // field 4 of stat
+		t.ppid = Pid(ppid)
+	}
+
+	f, err := os.Open(filepath.Join("/proc", pid.String(), "status"))
+	if err != nil {
+		return t
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if value, ok := strings.CutPrefix(sc.Text(), "Uid:"); ok {
+			if fields := strings.Fields(value); len(fields) > 0 {
+				t.uid, _ = strconv.Atoi(fields[0])
+			}
+			break
+		}
+	}
+	return t
+}
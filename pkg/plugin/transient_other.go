@@ -0,0 +1,21 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+//go:build !linux
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// processEvents is only observed for Linux processes.
+func processEvents(context.Context, func(Pid), func(Pid)) error {
+	return errors.New("process events are only observed on Linux")
+}
+
+// readTransient is only determined for Linux processes.
+func readTransient(pid Pid) *transient {
+	return &transient{pid: pid, exec: time.Now()}
+}
@@ -0,0 +1,40 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddTransients(t *testing.T) {
+	transients.Lock()
+	saved := transients.exited
+	exited := time.Now()
+	transients.exited = []*transient{{pid: 99, ppid: 20, name: "sh", exec: exited.Add(-time.Second), exit: exited}}
+	transients.Unlock()
+	defer func() {
+		transients.Lock()
+		transients.exited = saved
+		transients.Unlock()
+	}()
+
+	tb := snapshotTable()
+	query := testQuery(queryModel{ShowTransients: true}, dataSourceSettings{})
+	query.live = true
+	g := query.selected(tb)
+
+	if tb[99] != nil {
+		t.Error("transient added to the shared table")
+	}
+	if g.processes != len(tb) {
+		t.Errorf("process count %d includes transients, want %d", g.processes, len(tb))
+	}
+	found := false
+	for _, n := range g.nodes {
+		found = found || pidOf(Pid(n[0].(int64))) == 99 && n[transientDetail] != ""
+	}
+	if !found {
+		t.Error("transient is not a node")
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"github.com/zosmac/gomon/process"
+)
+
+// tree builds the parent/child process tree of all processes, or of the query pid's family, as a node graph.
+// The processes' connections are not needed, so the live tree is built without collecting them. The tree is
+// filtered and finished as the graph of the processes' connections is.
+func (query Query) tree(tb process.Table) graph {
+	tb = adoptOrphans(tb)
+	tr := tb.BuildTree()
+	if pid := query.model.Pid; pid > 0 && tb[pid] != nil {
+		tr = tr.Family(pid)
+	}
+	include := process.Table{}
+	for _, pid := range tr.All() {
+		include[pid] = tb[pid]
+	}
+	return query.assemble(tb, include, map[Pid][]any{}, map[Pid][]any{}, map[[2]Pid][]any{})
+}
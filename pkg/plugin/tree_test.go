@@ -0,0 +1,53 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTreeFiltered(t *testing.T) {
+	tb := snapshotTable()
+	tb[20].Id.Starttime = time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+	query := testQuery(queryModel{TreeOnly: true, ExcludePids: []Pid{30}}, dataSourceSettings{})
+	g := query.tree(tb)
+
+	ids := map[Pid]Pid{}
+	for _, n := range g.nodes {
+		id := Pid(n[0].(int64))
+		ids[pidOf(id)] = id
+	}
+	if _, ok := ids[30]; ok {
+		t.Error("excluded process is a node of the tree")
+	}
+	if _, ok := ids[21]; !ok {
+		t.Errorf("process 21 is not a node of the tree: %v", ids)
+	}
+	if ids[20] == 20 {
+		t.Error("tree node id is not qualified by the process' start time")
+	}
+	for _, e := range g.edges {
+		if !parentEdge(e) {
+			t.Errorf("tree edge %v is not a parent edge", e[0])
+		}
+	}
+}
+
+func TestTreeDepthFromRoots(t *testing.T) {
+	tb := snapshotTable()
+	tb[22] = testProcess(22, 21, "helper")
+	depth := 1
+	g := testQuery(queryModel{TreeOnly: true, Depth: &depth}, dataSourceSettings{}).tree(tb)
+
+	ids := map[Pid]bool{}
+	for _, n := range g.nodes {
+		ids[pidOf(Pid(n[0].(int64)))] = true
+	}
+	if !ids[1] || !ids[20] || !ids[30] {
+		t.Errorf("root and its children are not nodes: %v", ids)
+	}
+	if ids[21] || ids[22] {
+		t.Errorf("descendants beyond depth 1 of the root are nodes: %v", ids)
+	}
+}
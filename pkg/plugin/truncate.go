@@ -0,0 +1,134 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// truncate limits the graph to the query's maximum nodes and edges, retaining the pinned processes, the nodes with
+// remote connections, and the most connected nodes. A host or data node is only retained with a process it connects to.
+func (query Query) truncate(g *graph) {
+	maxNodes, maxEdges := query.model.MaxNodes, query.model.MaxEdges
+	if (maxNodes <= 0 || len(g.nodes) <= maxNodes) && (maxEdges <= 0 || len(g.edges) <= maxEdges) {
+		return
+	}
+	nodeCount, edgeCount := len(g.nodes), len(g.edges)
+
+	degree := map[int64]int{}
+	remote := map[int64]bool{}
+	neighbors := map[int64][]int64{}
+	pinned := func(id int64) bool {
+		return slices.Contains(query.model.PinPids, Pid(id))
+	}
+	for _, e := range g.edges {
+		source, target := e[1].(int64), e[2].(int64)
+		degree[source]++
+		degree[target]++
+		neighbors[source] = append(neighbors[source], target)
+		neighbors[target] = append(neighbors[target], source)
+		if source < 0 { // host connection
+			remote[source] = true
+			remote[target] = true
+		}
+	}
+
+	if maxNodes > 0 && len(g.nodes) > maxNodes {
+		ranked := make([]int64, len(g.nodes))
+		for i, n := range g.nodes {
+			ranked[i] = n[0].(int64)
+		}
+		slices.SortStableFunc(ranked, func(a, b int64) int {
+			if pa, pb := pinned(a), pinned(b); pa != pb {
+				if pa {
+					return -1
+				}
+				return 1
+			}
+			if remote[a] != remote[b] {
+				if remote[a] {
+					return -1
+				}
+				return 1
+			}
+			return cmp.Compare(degree[b], degree[a])
+		})
+
+		kept := map[int64]bool{}
+		for changed := true; changed && len(kept) < maxNodes; {
+			changed = false
+			for _, id := range ranked {
+				if len(kept) >= maxNodes {
+					break
+				}
+				if kept[id] {
+					continue
+				}
+				if isProcess(Pid(id)) || slices.ContainsFunc(neighbors[id], func(n int64) bool {
+					return kept[n]
+				}) {
+					kept[id] = true
+					changed = true
+				}
+			}
+		}
+
+		g.nodes = slices.DeleteFunc(g.nodes, func(n []any) bool {
+			return !kept[n[0].(int64)]
+		})
+		g.edges = slices.DeleteFunc(g.edges, func(e []any) bool {
+			return !kept[e[1].(int64)] || !kept[e[2].(int64)]
+		})
+	}
+
+	if maxEdges > 0 && len(g.edges) > maxEdges {
+		ranked := slices.Clone(g.edges)
+		slices.SortStableFunc(ranked, func(a, b []any) int {
+			pa := pinned(a[1].(int64)) || pinned(a[2].(int64))
+			pb := pinned(b[1].(int64)) || pinned(b[2].(int64))
+			if pa != pb {
+				if pa {
+					return -1
+				}
+				return 1
+			}
+			ra, rb := a[1].(int64) < 0, b[1].(int64) < 0
+			if ra != rb {
+				if ra {
+					return -1
+				}
+				return 1
+			}
+			return cmp.Compare(len(b), len(a))
+		})
+		kept := map[string]bool{}
+		for _, e := range ranked[:maxEdges] {
+			kept[e[0].(string)] = true
+		}
+		g.edges = slices.DeleteFunc(g.edges, func(e []any) bool {
+			return !kept[e[0].(string)]
+		})
+
+		// drop the host and data nodes left without edges
+		referenced := map[int64]bool{}
+		for _, e := range g.edges {
+			referenced[e[1].(int64)] = true
+			referenced[e[2].(int64)] = true
+		}
+		g.nodes = slices.DeleteFunc(g.nodes, func(n []any) bool {
+			return !isProcess(Pid(n[0].(int64))) && !referenced[n[0].(int64)]
+		})
+	}
+
+	// the connection fields of the edges are sized by the most connected edge retained
+	g.maxConnections = 0
+	for _, e := range g.edges {
+		g.maxConnections = max(g.maxConnections, len(e)-connIndex)
+	}
+
+	query.notices.add(data.NoticeSeverityWarning, "graph truncated to %d of %d nodes and %d of %d edges",
+		len(g.nodes), nodeCount, len(g.edges), edgeCount)
+}
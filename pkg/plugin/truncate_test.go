@@ -0,0 +1,39 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/zosmac/gomon/process"
+)
+
+func TestTruncate(t *testing.T) {
+	tb := process.Table{
+		1:  testProcess(1, 0, "init"),
+		20: testProcess(20, 1, "server"),
+		30: testProcess(30, 1, "client"),
+		40: testProcess(40, 1, "busy"),
+	}
+	query := testQuery(queryModel{MaxEdges: 1, PinPids: []Pid{30}}, dataSourceSettings{})
+	busy := query.ProcEdge(tb, 20, 40)
+	for _, conn := range []string{"a", "b", "c", "d", "e"} {
+		busy = append(busy, "unix:"+conn)
+	}
+	g := graph{
+		nodes:          [][]any{{int64(1)}, {int64(20)}, {int64(30)}, {int64(40)}},
+		edges:          [][]any{append(query.ProcEdge(tb, 20, 30), "unix:pinned"), busy},
+		maxConnections: 5,
+	}
+
+	query.truncate(&g)
+	if len(g.edges) != 1 || g.edges[0][2] != int64(30) {
+		t.Fatalf("truncated edges %v, want the pinned edge", g.edges)
+	}
+	if g.maxConnections != 1 {
+		t.Errorf("max connections %d, want 1 of the retained edge", g.maxConnections)
+	}
+	if len(query.notices.list) != 1 {
+		t.Errorf("notices %v, want the truncation notice", query.notices.list)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/zosmac/gomon/process"
+)
+
+const (
+	// defaultFanOut is the count of remote hosts a process connects to that flags it, if not configured.
+	defaultFanOut = 10
+
+	// reexecWarning prefixes the reason that the reexec heuristic flags a process with its prior executable.
+	reexecWarning = "re-exec'd from "
+)
+
+var (
+	// warningHeuristics are the names of the heuristics that may flag a process node with the warning arc.
+	warningHeuristics = []string{"root", "reexec", "fanout"}
+)
+
+// validateWarnings checks that the settings' warnings name known heuristics.
+func (settings dataSourceSettings) validateWarnings() error {
+	for i, warning := range settings.Warnings {
+		if !slices.Contains(warningHeuristics, warning) {
+			return fmt.Errorf("warnings[%d] %q is not one of %s", i, warning, strings.Join(warningHeuristics, ", "))
+		}
+	}
+	return nil
+}
+
+// warnings reports the reasons that the settings' warning heuristics flag a process, none if it is not flagged.
+func (query Query) warnings(p *process.Process, reexec string, remotes int64) []string {
+	fanOut := int64(defaultFanOut)
+	if query.settings.FanOut > 0 {
+		fanOut = int64(query.settings.FanOut)
+	}
+	var reasons []string
+	for _, warning := range query.settings.Warnings {
+		switch warning {
+		case "root":
+			if p.UID == 0 {
+				reasons = append(reasons, "running as root")
+			}
+		case "reexec":
+			if reexec != "" {
+				reasons = append(reasons, reexecWarning+reexec)
+			}
+		case "fanout":
+			if remotes >= fanOut {
+				reasons = append(reasons, fmt.Sprintf("connected to %d remote hosts", remotes))
+			}
+		}
+	}
+	return reasons
+}
+
+// warn splits a flagged node's circle between its type's arc and the warning arc.
+func warn(node []any, a int) {
+	node[4+a] = 0.5
+	node[4+warnArc] = 0.5
+}
@@ -0,0 +1,124 @@
+// Copyright © 2021-2023 The Gomon Project.
+
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// maxWindowNodes and maxWindowEdges cap the size of the union of a window's snapshots.
+	maxWindowNodes = 2000
+	maxWindowEdges = 5000
+)
+
+var (
+	// seenDetail and lastSeenDetail are the indices in an edge of the count of a window's snapshots that include
+	// it, and the time of the latest.
+	seenDetail     = edgeDetailIndex("seen")
+	lastSeenDetail = edgeDetailIndex("lastSeen")
+)
+
+// window returns the retained snapshots recorded within a time range, oldest first.
+func (s *snapshots) window(from, to time.Time) []snapshot {
+	s.Lock()
+	defer s.Unlock()
+	var snaps []snapshot
+	for _, snap := range s.tables {
+		if !snap.timestamp.Before(from) && !snap.timestamp.After(to) {
+			snaps = append(snaps, snap)
+		}
+	}
+	slices.SortFunc(snaps, func(a, b snapshot) int {
+		return cmp.Compare(a.timestamp.UnixNano(), b.timestamp.UnixNano())
+	})
+	return snaps
+}
+
+// union merges the graphs of the retained snapshots of a time range, built with a query's options, into one graph,
+// so that nodes and edges that appeared briefly are reported. The latest version of each node and edge is kept,
+// each edge reporting its connections in any of the graphs, how many of them include it, and when it was last seen.
+func (s *snapshots) union(ctx context.Context, model queryModel, settings dataSourceSettings, from, to time.Time) graph {
+	var gs []graph
+	for _, snap := range s.window(from, to) {
+		gs = append(gs, snap.build(ctx, model, settings))
+	}
+	n := notices{}
+	if len(gs) == 0 {
+		n.add(data.NoticeSeverityWarning, "no snapshots were retained between %s and %s",
+			from.Format(time.DateTime), to.Format(time.DateTime))
+		return graph{timestamp: to, notices: n.list}
+	}
+	if gs[0].timestamp.Sub(from) > s.interval || to.Sub(gs[len(gs)-1].timestamp) > s.interval {
+		n.add(data.NoticeSeverityInfo, "the %d snapshots cover %s to %s of the window",
+			len(gs), gs[0].timestamp.Format(time.DateTime), gs[len(gs)-1].timestamp.Format(time.DateTime))
+	}
+
+	u := graph{timestamp: gs[len(gs)-1].timestamp}
+	nodes := map[int64]struct{}{}
+	edges := map[string][]any{}
+	capped := false
+	for i := len(gs) - 1; i >= 0; i-- { // latest first, so the latest versions are kept
+		g := gs[i]
+		for _, node := range g.nodes {
+			if _, ok := nodes[node[0].(int64)]; ok {
+				continue
+			}
+			if len(nodes) == maxWindowNodes {
+				capped = true
+				continue
+			}
+			nodes[node[0].(int64)] = struct{}{}
+			u.nodes = append(u.nodes, node)
+		}
+		for _, edge := range g.edges {
+			id := edge[0].(string)
+			e, ok := edges[id]
+			if !ok {
+				if len(edges) == maxWindowEdges {
+					capped = true
+					continue
+				}
+				e = slices.Clone(edge)
+				seen, last := int64(0), g.timestamp
+				e[seenDetail], e[lastSeenDetail] = &seen, &last
+			}
+			*e[seenDetail].(*int64)++
+			for _, conn := range edge[connIndex:] {
+				if !slices.Contains(e[connIndex:], conn) {
+					e = append(e, conn)
+				}
+			}
+			edges[id] = e
+		}
+	}
+	if capped {
+		n.add(data.NoticeSeverityWarning, "the union of the window's snapshots is capped at %d nodes and %d edges",
+			maxWindowNodes, maxWindowEdges)
+	}
+
+	// drop the edges to capped nodes
+	for _, e := range edges {
+		if _, ok := nodes[e[1].(int64)]; !ok {
+			continue
+		}
+		if _, ok := nodes[e[2].(int64)]; !ok {
+			continue
+		}
+		u.edges = append(u.edges, e)
+		u.maxConnections = max(u.maxConnections, len(e)-connIndex)
+	}
+	slices.SortFunc(u.edges, func(a, b []any) int {
+		return cmp.Or(
+			cmp.Compare(a[1].(int64), b[1].(int64)),
+			cmp.Compare(a[2].(int64), b[2].(int64)),
+		)
+	})
+	u.notices = n.list
+	return u
+}
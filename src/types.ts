@@ -8,6 +8,34 @@ export interface MyQuery extends DataQuery {
   graph?: string;
   pid: number;
   streaming: boolean;
+  groupByContainer?: boolean;
+  protocols?: string[];
+  threads?: boolean;
+  collapse?: number[];
+  maxNodes?: number;
+  maxEdges?: number;
+  remoteOnly?: boolean;
+  hideParentEdges?: boolean;
+  unit?: string;
+  depth?: number;
+  pinPids?: number[];
+  excludePids?: number[];
+  sizeBy?: string;
+  showListeners?: boolean;
+  fileFilter?: string;
+  peerHost?: string;
+  sharedMem?: boolean;
+  treeOnly?: boolean;
+  seedFile?: string;
+  seedHost?: string;
+  showSelfConnections?: boolean;
+  hideGroupEdges?: boolean;
+  fileTypes?: string[];
+  colorByName?: boolean;
+  crossBoundaryOnly?: boolean;
+  hideLoopback?: boolean;
+  showTransients?: boolean;
+  window?: boolean;
 }
 
 export const defaultQuery: MyQuery = {
@@ -21,6 +49,37 @@ export const defaultQuery: MyQuery = {
  * These are options configured for each DataSource instance.
  */
 export interface MyDataSourceOptions extends DataSourceJsonData {
+  snapshotRetention?: number;
+  snapshotInterval?: number;
+  hostnameTimeout?: number;
+  hostnameStyle?: string;
+  anonymize?: boolean;
+  edgeMainStat?: string;
+  edgeSecondaryStat?: string;
+  services?: { [port: string]: string };
+  encryptedPorts?: { [port: string]: boolean | null };
+  denylist?: string[];
+  internalNetworks?: string[];
+  streamMinInterval?: number;
+  streamMaxInterval?: number;
+  warnings?: string[];
+  fanOut?: number;
+  gpuDevices?: string[];
+  profile?: boolean;
+  conntrack?: boolean;
+  groups?: Array<{ name: string; pattern: string }>;
+  queryTimeout?: number;
+  replayFile?: string;
+  remoteURL?: string;
+  transients?: boolean;
+  hideSelf?: boolean;
+}
+
+/**
+ * Value that is used in the backend, but never sent over HTTP to the frontend
+ */
+export interface MySecureJsonData {
+  remoteToken?: string;
 }
 
 export const defaultDataSourceOptions: Partial<MyDataSourceOptions> = {